@@ -1,8 +1,15 @@
 package ast
 
+import (
+	"compiler/token"
+	"strings"
+)
+
 // node in ast
 type Node interface {
 	TokenLiteral() string
+	Pos() token.Position // first character of the node
+	End() token.Position // one past the last character of the node
 }
 
 type Statement interface {
@@ -28,3 +35,50 @@ func (p *Program) TokenLiteral() string {
 	}
 	return ss
 }
+
+func (p *Program) String() string {
+	return joinStatements(p.Statements, "\n")
+}
+
+func (p *Program) Pos() token.Position {
+	if len(p.Statements) == 0 {
+		return token.Position{}
+	}
+	return p.Statements[0].Pos()
+}
+
+func (p *Program) End() token.Position {
+	if len(p.Statements) == 0 {
+		return token.Position{}
+	}
+	return p.Statements[len(p.Statements)-1].End()
+}
+
+// NOTE: children can be missing after a parse error, fall back to the token
+func posOf(n Node, fallback token.Position) token.Position {
+	if n == nil {
+		return fallback
+	}
+	return n.Pos()
+}
+
+func endOf(n Node, fallback token.Position) token.Position {
+	if n == nil {
+		return fallback
+	}
+	return n.End()
+}
+
+// separate statements with ; unless they already end with one,
+// so the result parses back to the same statements
+func joinStatements(stmts []Statement, sep string) string {
+	ss := []string{}
+	for i, stmt := range stmts {
+		s := stmt.String()
+		if i != len(stmts)-1 && !strings.HasSuffix(s, ";") {
+			s += ";"
+		}
+		ss = append(ss, s)
+	}
+	return strings.Join(ss, sep)
+}
@@ -1,14 +1,27 @@
 package ast
 
-import "compiler/token"
+import (
+	"compiler/token"
+	"strings"
+)
 
 var _ Expression = (*PrefixExpression)(nil)
 var _ Expression = (*InfixExpression)(nil)
 var _ Expression = (*SuffixExpression)(nil)
 var _ Expression = (*Identifier)(nil)
 var _ Expression = (*IntegerLiteral)(nil)
+var _ Expression = (*FloatLiteral)(nil)
+var _ Expression = (*StringLiteral)(nil)
 var _ Expression = (*Boolean)(nil)
 var _ Expression = (*IfExpreesion)(nil)
+var _ Expression = (*ConditionalExpression)(nil)
+var _ Expression = (*AssignExpression)(nil)
+var _ Expression = (*FnExpression)(nil)
+var _ Expression = (*CallExpression)(nil)
+var _ Expression = (*ArrayLiteral)(nil)
+var _ Expression = (*IndexExpression)(nil)
+var _ Expression = (*SliceExpression)(nil)
+var _ Expression = (*HashLiteral)(nil)
 
 // TODO(dingwang): Distinguish left and right
 type Identifier struct {
@@ -27,6 +40,14 @@ func (i *Identifier) String() string {
 	return i.TokenLiteral()
 }
 
+func (i *Identifier) Pos() token.Position {
+	return i.Token.Pos
+}
+
+func (i *Identifier) End() token.Position {
+	return i.Token.End
+}
+
 type IntegerLiteral struct {
 	Token token.Token
 	Value int64
@@ -42,6 +63,61 @@ func (i *IntegerLiteral) String() string {
 	return i.TokenLiteral()
 }
 
+func (i *IntegerLiteral) Pos() token.Position {
+	return i.Token.Pos
+}
+
+func (i *IntegerLiteral) End() token.Position {
+	return i.Token.End
+}
+
+type FloatLiteral struct {
+	Token token.Token
+	Value float64
+}
+
+func (f *FloatLiteral) TokenLiteral() string {
+	return f.Token.Literal
+}
+
+func (f *FloatLiteral) expressionNode() {}
+
+func (f *FloatLiteral) String() string {
+	return f.TokenLiteral()
+}
+
+func (f *FloatLiteral) Pos() token.Position {
+	return f.Token.Pos
+}
+
+func (f *FloatLiteral) End() token.Position {
+	return f.Token.End
+}
+
+// Token.Literal keeps the quotes and escapes as written, Value is decoded
+type StringLiteral struct {
+	Token token.Token
+	Value string
+}
+
+func (s *StringLiteral) TokenLiteral() string {
+	return s.Token.Literal
+}
+
+func (s *StringLiteral) expressionNode() {}
+
+func (s *StringLiteral) String() string {
+	return s.TokenLiteral()
+}
+
+func (s *StringLiteral) Pos() token.Position {
+	return s.Token.Pos
+}
+
+func (s *StringLiteral) End() token.Position {
+	return s.Token.End
+}
+
 type PrefixExpression struct {
 	Token token.Token
 	Right Expression
@@ -59,6 +135,14 @@ func (ie *PrefixExpression) String() string {
 	return "(" + ie.TokenLiteral() + ie.Right.String() + ")"
 }
 
+func (ie *PrefixExpression) Pos() token.Position {
+	return ie.Token.Pos
+}
+
+func (ie *PrefixExpression) End() token.Position {
+	return endOf(ie.Right, ie.Token.End)
+}
+
 type InfixExpression struct {
 	Token token.Token
 	Left  Expression
@@ -77,6 +161,14 @@ func (ie *InfixExpression) String() string {
 	return "(" + ie.Left.String() + " " + ie.TokenLiteral() + " " + ie.Right.String() + ")"
 }
 
+func (ie *InfixExpression) Pos() token.Position {
+	return posOf(ie.Left, ie.Token.Pos)
+}
+
+func (ie *InfixExpression) End() token.Position {
+	return endOf(ie.Right, ie.Token.End)
+}
+
 type SuffixExpression struct {
 	Token token.Token
 	Left  Expression
@@ -94,6 +186,14 @@ func (ie *SuffixExpression) String() string {
 	return "(" + ie.Left.String() + ie.TokenLiteral() + ")"
 }
 
+func (ie *SuffixExpression) Pos() token.Position {
+	return posOf(ie.Left, ie.Token.Pos)
+}
+
+func (ie *SuffixExpression) End() token.Position {
+	return ie.Token.End
+}
+
 type Boolean struct {
 	Token token.Token
 	Value bool
@@ -111,6 +211,14 @@ func (b *Boolean) String() string {
 	return "(" + b.TokenLiteral() + ")"
 }
 
+func (b *Boolean) Pos() token.Position {
+	return b.Token.Pos
+}
+
+func (b *Boolean) End() token.Position {
+	return b.Token.End
+}
+
 type IfExpreesion struct {
 	Token       token.Token
 	Condition   Expression
@@ -131,11 +239,84 @@ func (expr *IfExpreesion) String() string {
 	s += " (" + expr.Condition.String() + ")"
 	s += expr.Consequence.String()
 	if expr.Alternatvie != nil {
-		s += expr.Alternatvie.String()
+		s += "else" + expr.Alternatvie.String()
 	}
 	return s
 }
 
+func (expr *IfExpreesion) Pos() token.Position {
+	return expr.Token.Pos
+}
+
+func (expr *IfExpreesion) End() token.Position {
+	if expr.Alternatvie != nil {
+		return expr.Alternatvie.End()
+	}
+	if expr.Consequence != nil {
+		return expr.Consequence.End()
+	}
+	return expr.Token.End
+}
+
+// <cond> ? <expr> : <expr>
+type ConditionalExpression struct {
+	Token       token.Token // just ?
+	Condition   Expression
+	Consequence Expression
+	Alternative Expression
+}
+
+func (expr *ConditionalExpression) TokenLiteral() string {
+	return expr.Token.Literal
+}
+
+func (expr *ConditionalExpression) expressionNode() {
+
+}
+
+func (expr *ConditionalExpression) String() string {
+	return "(" + expr.Condition.String() + " ? " + expr.Consequence.String() + " : " + expr.Alternative.String() + ")"
+}
+
+func (expr *ConditionalExpression) Pos() token.Position {
+	return posOf(expr.Condition, expr.Token.Pos)
+}
+
+func (expr *ConditionalExpression) End() token.Position {
+	if expr.Alternative != nil {
+		return expr.Alternative.End()
+	}
+	return endOf(expr.Consequence, expr.Token.End)
+}
+
+// <target> = <expr> or a compound like <target> += <expr>, the target is
+// an Identifier or an IndexExpression
+type AssignExpression struct {
+	Token  token.Token // =, +=, -=, *= or /=
+	Target Expression
+	Value  Expression
+}
+
+func (expr *AssignExpression) TokenLiteral() string {
+	return expr.Token.Literal
+}
+
+func (expr *AssignExpression) expressionNode() {
+
+}
+
+func (expr *AssignExpression) String() string {
+	return "(" + expr.Target.String() + " " + expr.TokenLiteral() + " " + expr.Value.String() + ")"
+}
+
+func (expr *AssignExpression) Pos() token.Position {
+	return posOf(expr.Target, expr.Token.Pos)
+}
+
+func (expr *AssignExpression) End() token.Position {
+	return endOf(expr.Value, expr.Token.End)
+}
+
 type FnExpression struct {
 	Token token.Token
 	Param []Identifier
@@ -154,13 +335,195 @@ func (expr *FnExpression) String() string {
 	s := "fn("
 	for _, iden := range expr.Param {
 		s += iden.TokenLiteral()
-		s += ","
+		s += ", "
 	}
 	if len(expr.Param) != 0 {
-		s = s[:len(s)-1]
+		s = s[:len(s)-2]
 	}
-	s += ") {"
+	s += ") "
 	s += expr.Body.String()
-	s += "}"
 	return s
 }
+
+func (expr *FnExpression) Pos() token.Position {
+	return expr.Token.Pos
+}
+
+func (expr *FnExpression) End() token.Position {
+	return expr.Body.End()
+}
+
+// <Expr>(<Expr>, <Expr>, ...)
+type CallExpression struct {
+	Token     token.Token // just (
+	Function  Expression
+	Arguments []Expression
+	Rparen    token.Token
+}
+
+func (expr *CallExpression) TokenLiteral() string {
+	return expr.Token.Literal
+}
+
+func (expr *CallExpression) expressionNode() {
+
+}
+
+func (expr *CallExpression) String() string {
+	args := []string{}
+	for _, arg := range expr.Arguments {
+		args = append(args, arg.String())
+	}
+	return expr.Function.String() + "(" + strings.Join(args, ", ") + ")"
+}
+
+func (expr *CallExpression) Pos() token.Position {
+	return posOf(expr.Function, expr.Token.Pos)
+}
+
+func (expr *CallExpression) End() token.Position {
+	if expr.Rparen.End.IsValid() {
+		return expr.Rparen.End
+	}
+	return expr.Token.End
+}
+
+// [<Expr>, <Expr>, ...]
+type ArrayLiteral struct {
+	Token    token.Token // just [
+	Elements []Expression
+	Rbracket token.Token
+}
+
+func (a *ArrayLiteral) TokenLiteral() string {
+	return a.Token.Literal
+}
+
+func (a *ArrayLiteral) expressionNode() {
+
+}
+
+func (a *ArrayLiteral) String() string {
+	elements := []string{}
+	for _, element := range a.Elements {
+		elements = append(elements, element.String())
+	}
+	return "[" + strings.Join(elements, ", ") + "]"
+}
+
+func (a *ArrayLiteral) Pos() token.Position {
+	return a.Token.Pos
+}
+
+func (a *ArrayLiteral) End() token.Position {
+	if a.Rbracket.End.IsValid() {
+		return a.Rbracket.End
+	}
+	return a.Token.End
+}
+
+// <Expr>[<Expr>]
+type IndexExpression struct {
+	Token    token.Token // just [
+	Left     Expression
+	Index    Expression
+	Rbracket token.Token
+}
+
+func (expr *IndexExpression) TokenLiteral() string {
+	return expr.Token.Literal
+}
+
+func (expr *IndexExpression) expressionNode() {
+
+}
+
+func (expr *IndexExpression) String() string {
+	return "(" + expr.Left.String() + "[" + expr.Index.String() + "])"
+}
+
+func (expr *IndexExpression) Pos() token.Position {
+	return posOf(expr.Left, expr.Token.Pos)
+}
+
+func (expr *IndexExpression) End() token.Position {
+	if expr.Rbracket.End.IsValid() {
+		return expr.Rbracket.End
+	}
+	return expr.Token.End
+}
+
+// <Expr>[<Expr>:<Expr>], Low and High are nil when left out
+type SliceExpression struct {
+	Token    token.Token // just [
+	Left     Expression
+	Low      Expression
+	High     Expression
+	Rbracket token.Token
+}
+
+func (expr *SliceExpression) TokenLiteral() string {
+	return expr.Token.Literal
+}
+
+func (expr *SliceExpression) expressionNode() {
+
+}
+
+func (expr *SliceExpression) String() string {
+	s := "(" + expr.Left.String() + "["
+	if expr.Low != nil {
+		s += expr.Low.String()
+	}
+	s += ":"
+	if expr.High != nil {
+		s += expr.High.String()
+	}
+	return s + "])"
+}
+
+func (expr *SliceExpression) Pos() token.Position {
+	return posOf(expr.Left, expr.Token.Pos)
+}
+
+func (expr *SliceExpression) End() token.Position {
+	if expr.Rbracket.End.IsValid() {
+		return expr.Rbracket.End
+	}
+	return expr.Token.End
+}
+
+// {<Expr>: <Expr>, ...}, Keys[i] maps to Values[i], in source order
+type HashLiteral struct {
+	Token  token.Token // just {
+	Keys   []Expression
+	Values []Expression
+	Rbrace token.Token
+}
+
+func (h *HashLiteral) TokenLiteral() string {
+	return h.Token.Literal
+}
+
+func (h *HashLiteral) expressionNode() {
+
+}
+
+func (h *HashLiteral) String() string {
+	pairs := []string{}
+	for i, key := range h.Keys {
+		pairs = append(pairs, key.String()+": "+h.Values[i].String())
+	}
+	return "{" + strings.Join(pairs, ", ") + "}"
+}
+
+func (h *HashLiteral) Pos() token.Position {
+	return h.Token.Pos
+}
+
+func (h *HashLiteral) End() token.Position {
+	if h.Rbrace.End.IsValid() {
+		return h.Rbrace.End
+	}
+	return h.Token.End
+}
@@ -0,0 +1,94 @@
+package ast
+
+import (
+	"compiler/token"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+)
+
+var (
+	nodeType  = reflect.TypeOf((*Node)(nil)).Elem()
+	tokenType = reflect.TypeOf(token.Token{})
+)
+
+// write the tree under node, one node per line, children indented
+//
+//	LetStatement 1:1-1:10 token="let"
+//	  Name: Identifier 1:5-1:6 token="x" Value="x"
+//	  Value: IntegerLiteral 1:9-1:10 token="5" Value=5
+func Fprint(w io.Writer, node Node) {
+	printer := &printer{w: w}
+	printer.print("", reflect.ValueOf(node), 0)
+}
+
+type printer struct {
+	w io.Writer
+}
+
+func (p *printer) print(label string, v reflect.Value, depth int) {
+	indent := strings.Repeat("  ", depth)
+
+	if v.Kind() == reflect.Interface || v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			fmt.Fprintf(p.w, "%s%s<nil>\n", indent, label)
+			return
+		}
+	}
+	if v.Kind() == reflect.Interface {
+		v = v.Elem()
+	}
+
+	switch {
+	case v.Kind() == reflect.Slice:
+		fmt.Fprintf(p.w, "%s%s[%d]\n", indent, label, v.Len())
+		for i := 0; i < v.Len(); i++ {
+			p.print(fmt.Sprintf("%d: ", i), v.Index(i), depth+1)
+		}
+	case v.Type().Implements(nodeType):
+		p.printNode(label, v, depth)
+	case v.CanAddr() && v.Addr().Type().Implements(nodeType):
+		// nodes stored by value, like FnExpression.Body
+		p.printNode(label, v.Addr(), depth)
+	default:
+		fmt.Fprintf(p.w, "%s%s%#v\n", indent, label, v.Interface())
+	}
+}
+
+func (p *printer) printNode(label string, v reflect.Value, depth int) {
+	node := v.Interface().(Node)
+	s := v.Elem()
+
+	line := strings.Repeat("  ", depth) + label + s.Type().Name()
+	line += fmt.Sprintf(" %d:%d-%d:%d", node.Pos().Line, node.Pos().Column, node.End().Line, node.End().Column)
+
+	children := []int{}
+	for i := 0; i < s.NumField(); i++ {
+		field := s.Field(i)
+		switch {
+		case s.Type().Field(i).Name == "Token":
+			line += fmt.Sprintf(" token=%q", field.Interface().(token.Token).Literal)
+		case field.Type() == tokenType:
+			// closing tokens only matter for positions
+		case isLeaf(field):
+			line += fmt.Sprintf(" %s=%#v", s.Type().Field(i).Name, field.Interface())
+		default:
+			children = append(children, i)
+		}
+	}
+	fmt.Fprintln(p.w, line)
+
+	for _, i := range children {
+		p.print(s.Type().Field(i).Name+": ", s.Field(i), depth+1)
+	}
+}
+
+func isLeaf(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Bool, reflect.Int, reflect.Int64, reflect.Uint64, reflect.Float64, reflect.String:
+		return true
+	default:
+		return false
+	}
+}
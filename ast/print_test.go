@@ -0,0 +1,47 @@
+package ast_test
+
+import (
+	"bytes"
+	"compiler/ast"
+	"compiler/lexer"
+	"compiler/parser"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFprint(t *testing.T) {
+	p := parser.New(lexer.New("let f = fn(x) { -x };\nf(2)"))
+	program := p.ParseProgram()
+	require.Empty(t, p.Errors())
+
+	var out bytes.Buffer
+	ast.Fprint(&out, program)
+
+	expect := `Program 1:1-2:5
+  Statements: [2]
+    0: LetStatement 1:1-1:21 token="let"
+      Name: Identifier 1:5-1:6 token="f" Value="f"
+      Value: FnExpression 1:9-1:21 token="fn"
+        Param: [1]
+          0: Identifier 1:12-1:13 token="x" Value="x"
+        Body: BlockStatement 1:15-1:21 token="{"
+          Statements: [1]
+            0: ExpressionStatement 1:17-1:19 token="-"
+              Expression: PrefixExpression 1:17-1:19 token="-"
+                Right: Identifier 1:18-1:19 token="x" Value="x"
+    1: ExpressionStatement 2:1-2:5 token="f"
+      Expression: CallExpression 2:1-2:5 token="("
+        Function: Identifier 2:1-2:2 token="f" Value="f"
+        Arguments: [1]
+          0: IntegerLiteral 2:3-2:4 token="2" Value=2
+`
+	assert.Equal(t, expect, out.String())
+}
+
+func TestFprintNil(t *testing.T) {
+	var out bytes.Buffer
+	ast.Fprint(&out, &ast.ReturnStatement{})
+	assert.Equal(t, "ReturnStatement 0:0-0:0 token=\"\"\n  Value: <nil>\n", out.String())
+}
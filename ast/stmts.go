@@ -5,6 +5,10 @@ import "compiler/token"
 var _ Statement = (*LetStatement)(nil)
 var _ Statement = (*ReturnStatement)(nil)
 var _ Statement = (*ExpressionStatement)(nil)
+var _ Statement = (*WhileStatement)(nil)
+var _ Statement = (*ForStatement)(nil)
+var _ Statement = (*BreakStatement)(nil)
+var _ Statement = (*ContinueStatement)(nil)
 
 type LetStatement struct {
 	Token token.Token
@@ -21,7 +25,25 @@ func (ls *LetStatement) TokenLiteral() string {
 }
 
 func (ls *LetStatement) String() string {
-	return ""
+	s := "let " + ls.Name.String() + " ="
+	if ls.Value != nil {
+		s += " " + ls.Value.String()
+	}
+	return s + ";"
+}
+
+func (ls *LetStatement) Pos() token.Position {
+	return ls.Token.Pos
+}
+
+func (ls *LetStatement) End() token.Position {
+	if ls.Value != nil {
+		return ls.Value.End()
+	}
+	if ls.Name != nil {
+		return ls.Name.End()
+	}
+	return ls.Token.End
 }
 
 type ReturnStatement struct {
@@ -38,7 +60,18 @@ func (ls *ReturnStatement) TokenLiteral() string {
 }
 
 func (ls *ReturnStatement) String() string {
-	return ""
+	if ls.Value == nil {
+		return "return;"
+	}
+	return "return " + ls.Value.String() + ";"
+}
+
+func (ls *ReturnStatement) Pos() token.Position {
+	return ls.Token.Pos
+}
+
+func (ls *ReturnStatement) End() token.Position {
+	return endOf(ls.Value, ls.Token.End)
 }
 
 type ExpressionStatement struct {
@@ -57,9 +90,18 @@ func (s *ExpressionStatement) String() string {
 	return s.Expression.String()
 }
 
+func (s *ExpressionStatement) Pos() token.Position {
+	return posOf(s.Expression, s.Token.Pos)
+}
+
+func (s *ExpressionStatement) End() token.Position {
+	return endOf(s.Expression, s.Token.End)
+}
+
 type BlockStatement struct {
-	Token token.Token // just {
+	Token      token.Token // just {
 	Statements []Statement
+	Rbrace     token.Token
 }
 
 func (s *BlockStatement) TokenLiteral() string {
@@ -70,10 +112,123 @@ func (s *BlockStatement) statementNode() {
 }
 
 func (s *BlockStatement) String() string {
-	st := "{"
-	for _, stmt := range(s.Statements) {
-		st += stmt.String()
+	return "{" + joinStatements(s.Statements, " ") + "}"
+}
+
+func (s *BlockStatement) Pos() token.Position {
+	return s.Token.Pos
+}
+
+func (s *BlockStatement) End() token.Position {
+	if s.Rbrace.End.IsValid() {
+		return s.Rbrace.End
 	}
-	st += "}"
-	return st
+	if len(s.Statements) != 0 {
+		return s.Statements[len(s.Statements)-1].End()
+	}
+	return s.Token.End
+}
+
+type WhileStatement struct {
+	Token     token.Token
+	Condition Expression
+	Body      *BlockStatement
+}
+
+func (s *WhileStatement) statementNode() {
+}
+
+func (s *WhileStatement) TokenLiteral() string {
+	return s.Token.Literal
+}
+
+func (s *WhileStatement) String() string {
+	return "while (" + s.Condition.String() + ") " + s.Body.String()
+}
+
+func (s *WhileStatement) Pos() token.Position {
+	return s.Token.Pos
+}
+
+func (s *WhileStatement) End() token.Position {
+	if s.Body != nil {
+		return s.Body.End()
+	}
+	return endOf(s.Condition, s.Token.End)
+}
+
+// for (<Variable> in <Iterable>) <Body>
+type ForStatement struct {
+	Token    token.Token
+	Variable *Identifier
+	Iterable Expression
+	Body     *BlockStatement
+}
+
+func (s *ForStatement) statementNode() {
+}
+
+func (s *ForStatement) TokenLiteral() string {
+	return s.Token.Literal
+}
+
+func (s *ForStatement) String() string {
+	return "for (" + s.Variable.String() + " in " + s.Iterable.String() + ") " + s.Body.String()
+}
+
+func (s *ForStatement) Pos() token.Position {
+	return s.Token.Pos
+}
+
+func (s *ForStatement) End() token.Position {
+	if s.Body != nil {
+		return s.Body.End()
+	}
+	return endOf(s.Iterable, s.Token.End)
+}
+
+type BreakStatement struct {
+	Token token.Token
+}
+
+func (s *BreakStatement) statementNode() {
+}
+
+func (s *BreakStatement) TokenLiteral() string {
+	return s.Token.Literal
+}
+
+func (s *BreakStatement) String() string {
+	return "break;"
+}
+
+func (s *BreakStatement) Pos() token.Position {
+	return s.Token.Pos
+}
+
+func (s *BreakStatement) End() token.Position {
+	return s.Token.End
+}
+
+type ContinueStatement struct {
+	Token token.Token
+}
+
+func (s *ContinueStatement) statementNode() {
+}
+
+func (s *ContinueStatement) TokenLiteral() string {
+	return s.Token.Literal
+}
+
+func (s *ContinueStatement) String() string {
+	return "continue;"
+}
+
+func (s *ContinueStatement) Pos() token.Position {
+	return s.Token.Pos
+}
+
+func (s *ContinueStatement) End() token.Position {
+	return s.Token.End
 }
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"compiler/ast"
+	"compiler/diagnostic"
+	"compiler/evaluator"
+	"compiler/format"
+	"compiler/lexer"
+	"compiler/object"
+	"compiler/parser"
+	"compiler/token"
+	"fmt"
+	"io"
+	"os"
+)
+
+// exit codes
+const (
+	exitOK    = 0
+	exitError = 1
+)
+
+func readSource(filename string) (string, bool) {
+	var src []byte
+	var err error
+	if filename == "-" {
+		src, err = io.ReadAll(os.Stdin)
+	} else {
+		src, err = os.ReadFile(filename)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "shagua: %v\n", err)
+		return "", false
+	}
+	return string(src), true
+}
+
+// parse filename, diagnostics are rendered to stderr
+func parseFile(filename string) (*ast.Program, bool) {
+	src, ok := readSource(filename)
+	if !ok {
+		return nil, false
+	}
+
+	p := parser.New(lexer.New(src, lexer.WithFilename(filename)))
+	program := p.ParseProgram()
+	if diags := p.Diagnostics(); len(diags) != 0 {
+		diagnostic.RenderAll(os.Stderr, src, diags)
+		return program, false
+	}
+	return program, true
+}
+
+func runFile(filename string) int {
+	program, ok := parseFile(filename)
+	if !ok {
+		return exitError
+	}
+
+	result := evaluator.Eval(program, object.NewEnvironment())
+	if err, ok := result.(*object.Error); ok {
+		fmt.Fprintln(os.Stderr, err.Inspect())
+		return exitError
+	}
+	if result != nil && result != evaluator.NULL {
+		fmt.Println(result.Inspect())
+	}
+	return exitOK
+}
+
+func printTokens(filename string) int {
+	src, ok := readSource(filename)
+	if !ok {
+		return exitError
+	}
+
+	l := lexer.New(src, lexer.WithFilename(filename), lexer.WithComments())
+	for tok := l.NextToken(); tok.Type != token.EOF; tok = l.NextToken() {
+		fmt.Printf("%d:%d-%d:%d\t%v\t%q\n",
+			tok.Pos.Line, tok.Pos.Column, tok.End.Line, tok.End.Column, tok.Type, tok.Literal)
+	}
+
+	if diags := l.Diagnostics(); len(diags) != 0 {
+		diagnostic.RenderAll(os.Stderr, src, diags)
+		return exitError
+	}
+	return exitOK
+}
+
+func printAst(filename string) int {
+	program, ok := parseFile(filename)
+	if program != nil {
+		ast.Fprint(os.Stdout, program)
+	}
+	if !ok {
+		return exitError
+	}
+	return exitOK
+}
+
+func checkFile(filename string) int {
+	if _, ok := parseFile(filename); !ok {
+		return exitError
+	}
+	return exitOK
+}
+
+// print the file in the canonical style, it is not rewritten
+func formatFile(filename string) int {
+	src, ok := readSource(filename)
+	if !ok {
+		return exitError
+	}
+
+	formatted, diags := format.Source(filename, src)
+	if len(diags) != 0 {
+		diagnostic.RenderAll(os.Stderr, src, diags)
+		return exitError
+	}
+	fmt.Print(formatted)
+	return exitOK
+}
@@ -0,0 +1,21 @@
+package diagnostic
+
+// lexer errors
+const (
+	IllegalCharacter    = "E0001"
+	UnterminatedString  = "E0002"
+	InvalidEscape       = "E0003"
+	UnterminatedComment = "E0004"
+	MalformedNumber     = "E0005"
+)
+
+// parser errors
+const (
+	UnexpectedToken   = "E0101"
+	MissingExpression = "E0102"
+	UnclosedBlock     = "E0103"
+	InvalidLiteral    = "E0104"
+	IntegerOverflow   = "E0105"
+	OutsideLoop       = "E0106"
+	InvalidAssignment = "E0107"
+)
@@ -0,0 +1,88 @@
+package diagnostic
+
+import (
+	"compiler/ast"
+	"compiler/token"
+	"fmt"
+)
+
+type Severity int
+
+const (
+	Error Severity = iota
+	Warning
+	Note
+)
+
+func (s Severity) String() string {
+	switch s {
+	case Error:
+		return "error"
+	case Warning:
+		return "warning"
+	case Note:
+		return "note"
+	default:
+		return fmt.Sprintf("Severity(%d)", int(s))
+	}
+}
+
+// half open range of source, End is one past the last character
+type Span struct {
+	Pos token.Position
+	End token.Position
+}
+
+func TokenSpan(tok token.Token) Span {
+	return Span{Pos: tok.Pos, End: tok.End}
+}
+
+func NodeSpan(node ast.Node) Span {
+	return Span{Pos: node.Pos(), End: node.End()}
+}
+
+// secondary location that helps explain the diagnostic
+type Label struct {
+	Span    Span
+	Message string
+}
+
+// a problem found in source code, reported by the lexer and the parser
+type Diagnostic struct {
+	Severity Severity
+	Code     string // see codes.go
+	Message  string
+	Span     Span
+	Labels   []Label
+	Notes    []string
+}
+
+var _ error = (*Diagnostic)(nil)
+
+func New(severity Severity, code string, span Span, format string, a ...interface{}) *Diagnostic {
+	return &Diagnostic{
+		Severity: severity,
+		Code:     code,
+		Message:  fmt.Sprintf(format, a...),
+		Span:     span,
+	}
+}
+
+func Errorf(code string, span Span, format string, a ...interface{}) *Diagnostic {
+	return New(Error, code, span, format, a...)
+}
+
+func (d *Diagnostic) WithLabel(span Span, format string, a ...interface{}) *Diagnostic {
+	d.Labels = append(d.Labels, Label{Span: span, Message: fmt.Sprintf(format, a...)})
+	return d
+}
+
+func (d *Diagnostic) WithNote(format string, a ...interface{}) *Diagnostic {
+	d.Notes = append(d.Notes, fmt.Sprintf(format, a...))
+	return d
+}
+
+// short one line form, file:line:column: message
+func (d *Diagnostic) Error() string {
+	return fmt.Sprintf("%v: %s", d.Span.Pos, d.Message)
+}
@@ -0,0 +1,118 @@
+package diagnostic
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+type marker struct {
+	span    Span
+	char    string
+	message string
+}
+
+// write d in the long form, quoting the source lines it points at
+//
+//	error[E0101]: Expect IDENT, got INT
+//	 --> main.mk:2:7
+//	  |
+//	2 |   let 5 = 1;
+//	  |       ^
+//	  = note: ...
+func Render(w io.Writer, source string, d *Diagnostic) {
+	lines := strings.Split(source, "\n")
+
+	header := d.Severity.String()
+	if d.Code != "" {
+		header += "[" + d.Code + "]"
+	}
+	fmt.Fprintf(w, "%s: %s\n", header, d.Message)
+
+	markers := []marker{{span: d.Span, char: "^"}}
+	for _, label := range d.Labels {
+		markers = append(markers, marker{span: label.Span, char: "-", message: label.Message})
+	}
+	sort.SliceStable(markers, func(i, j int) bool {
+		return markers[i].span.Pos.Line < markers[j].span.Pos.Line
+	})
+
+	width := 1
+	for _, m := range markers {
+		if n := len(fmt.Sprint(m.span.Pos.Line)); n > width {
+			width = n
+		}
+	}
+	gutter := strings.Repeat(" ", width)
+
+	fmt.Fprintf(w, "%s--> %v\n", gutter, d.Span.Pos)
+	fmt.Fprintf(w, "%s |\n", gutter)
+
+	lastLine := 0
+	for _, m := range markers {
+		line := m.span.Pos.Line
+		if line < 1 || line > len(lines) {
+			continue
+		}
+		text := strings.TrimSuffix(lines[line-1], "\r")
+		if line != lastLine {
+			fmt.Fprintf(w, "%*d | %s\n", width, line, text)
+			lastLine = line
+		}
+
+		underline := indentFor(text, m.span.Pos.Column) + strings.Repeat(m.char, underlineWidth(text, m.span))
+		if m.message != "" {
+			underline += " " + m.message
+		}
+		fmt.Fprintf(w, "%s | %s\n", gutter, underline)
+	}
+
+	for _, note := range d.Notes {
+		fmt.Fprintf(w, "%s = note: %s\n", gutter, note)
+	}
+}
+
+// render every diagnostic, separated by blank lines
+func RenderAll(w io.Writer, source string, diags []*Diagnostic) {
+	for i, d := range diags {
+		if i != 0 {
+			fmt.Fprintln(w)
+		}
+		Render(w, source, d)
+	}
+}
+
+// whitespace up to column, tabs are kept so the marker lines up with the source
+func indentFor(text string, column int) string {
+	var sb strings.Builder
+	for i, r := range []rune(text) {
+		if i >= column-1 {
+			break
+		}
+		if r == '\t' {
+			sb.WriteRune('\t')
+		} else {
+			sb.WriteRune(' ')
+		}
+	}
+	for i := utf8.RuneCountInString(text); i < column-1; i++ {
+		sb.WriteRune(' ')
+	}
+	return sb.String()
+}
+
+// spans over several lines are underlined to the end of the first one
+func underlineWidth(text string, span Span) int {
+	width := 0
+	if span.End.Line == span.Pos.Line {
+		width = span.End.Column - span.Pos.Column
+	} else if span.End.Line > span.Pos.Line {
+		width = utf8.RuneCountInString(text) - span.Pos.Column + 1
+	}
+	if width < 1 {
+		width = 1
+	}
+	return width
+}
@@ -0,0 +1,57 @@
+package diagnostic
+
+import (
+	"bytes"
+	"compiler/token"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func pos(line, column int) token.Position {
+	return token.Position{Filename: "main.mk", Line: line, Column: column}
+}
+
+func TestRender(t *testing.T) {
+	source := "let x = 1;\n\tlet y = add(x,\n  2;\n"
+
+	d := Errorf(UnexpectedToken, Span{Pos: pos(3, 4), End: pos(3, 5)}, "Expect ), got ;").
+		WithLabel(Span{Pos: pos(2, 13), End: pos(2, 14)}, "to match this (").
+		WithNote("argument lists are closed by )")
+
+	var out bytes.Buffer
+	Render(&out, source, d)
+
+	expect := "error[E0101]: Expect ), got ;\n" +
+		" --> main.mk:3:4\n" +
+		"  |\n" +
+		"2 | \tlet y = add(x,\n" +
+		"  | \t           - to match this (\n" +
+		"3 |   2;\n" +
+		"  |    ^\n" +
+		"  = note: argument lists are closed by )\n"
+	assert.Equal(t, expect, out.String())
+}
+
+func TestRenderSpanWidth(t *testing.T) {
+	source := "let s = \"abc\nlet t = 1"
+
+	table := []struct {
+		span   Span
+		expect string
+	}{
+		{Span{Pos: pos(1, 5), End: pos(1, 6)}, "  |     ^\n"},
+		{Span{Pos: pos(2, 5), End: pos(2, 5)}, "  |     ^\n"},
+		{Span{Pos: pos(1, 9), End: pos(2, 1)}, "  |         ^^^^\n"},
+		{Span{Pos: pos(2, 10), End: pos(2, 10)}, "  |          ^\n"},
+	}
+
+	for _, data := range table {
+		var out bytes.Buffer
+		Render(&out, source, New(Warning, "", data.span, "oops"))
+
+		lines := bytes.SplitAfter(out.Bytes(), []byte("\n"))
+		assert.Equal(t, "warning: oops\n", string(lines[0]))
+		assert.Equal(t, data.expect, string(lines[4]))
+	}
+}
@@ -0,0 +1,709 @@
+package evaluator
+
+import (
+	"compiler/ast"
+	"compiler/object"
+	"compiler/token"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// there is only one true, false and null, compare them by pointer
+var (
+	NULL  = &object.Null{}
+	TRUE  = &object.Boolean{Value: true}
+	FALSE = &object.Boolean{Value: false}
+
+	BREAK    = &object.Break{}
+	CONTINUE = &object.Continue{}
+)
+
+// walk the ast and produce a value
+func Eval(node ast.Node, env *object.Environment) object.Object {
+	switch node := node.(type) {
+	case nil:
+		return NULL
+
+	// statements
+	case *ast.Program:
+		return evalProgram(node, env)
+	case *ast.ExpressionStatement:
+		return Eval(node.Expression, env)
+	case *ast.BlockStatement:
+		return evalBlockStatement(node, env)
+	case *ast.LetStatement:
+		val := Eval(node.Value, env)
+		if isError(val) || isControl(val) {
+			return val
+		}
+		env.Set(node.Name.Value, val)
+		return nil
+	case *ast.ReturnStatement:
+		val := Eval(node.Value, env)
+		if isError(val) || isControl(val) {
+			return val
+		}
+		return &object.ReturnValue{Value: val}
+	case *ast.WhileStatement:
+		return evalWhileStatement(node, env)
+	case *ast.ForStatement:
+		return evalForStatement(node, env)
+	case *ast.BreakStatement:
+		return BREAK
+	case *ast.ContinueStatement:
+		return CONTINUE
+
+	// expressions
+	case *ast.IntegerLiteral:
+		return &object.Integer{Value: node.Value}
+	case *ast.FloatLiteral:
+		return &object.Float{Value: node.Value}
+	case *ast.StringLiteral:
+		return &object.String{Value: node.Value}
+	case *ast.Boolean:
+		return nativeBoolToBooleanObject(node.Value)
+	case *ast.Identifier:
+		return evalIdentifier(node, env)
+	case *ast.PrefixExpression:
+		right := Eval(node.Right, env)
+		if isError(right) || isControl(right) {
+			return right
+		}
+		return evalPrefixExpression(node.TokenLiteral(), right)
+	case *ast.InfixExpression:
+		left := Eval(node.Left, env)
+		if isError(left) || isControl(left) {
+			return left
+		}
+		if node.Token.Type == token.AND || node.Token.Type == token.OR {
+			return evalLogicalExpression(node, left, env)
+		}
+		right := Eval(node.Right, env)
+		if isError(right) || isControl(right) {
+			return right
+		}
+		return evalInfixExpression(node.TokenLiteral(), left, right)
+	case *ast.SuffixExpression:
+		left := Eval(node.Left, env)
+		if isError(left) || isControl(left) {
+			return left
+		}
+		return evalSuffixExpression(node.TokenLiteral(), left)
+	case *ast.IfExpreesion:
+		return evalIfExpression(node, env)
+	case *ast.ConditionalExpression:
+		condition := Eval(node.Condition, env)
+		if isError(condition) || isControl(condition) {
+			return condition
+		}
+		if isTruthy(condition) {
+			return Eval(node.Consequence, env)
+		}
+		return Eval(node.Alternative, env)
+	case *ast.AssignExpression:
+		return evalAssignExpression(node, env)
+	case *ast.FnExpression:
+		return &object.Function{
+			Parameters: node.Param,
+			Body:       &node.Body,
+			Env:        env,
+		}
+	case *ast.CallExpression:
+		function := Eval(node.Function, env)
+		if isError(function) || isControl(function) {
+			return function
+		}
+		args := evalExpressions(node.Arguments, env)
+		if len(args) == 1 && (isError(args[0]) || isControl(args[0])) {
+			return args[0]
+		}
+		return applyFunction(function, args)
+	case *ast.ArrayLiteral:
+		elements := evalExpressions(node.Elements, env)
+		if len(elements) == 1 && (isError(elements[0]) || isControl(elements[0])) {
+			return elements[0]
+		}
+		return &object.Array{Elements: elements}
+	case *ast.HashLiteral:
+		return evalHashLiteral(node, env)
+	case *ast.IndexExpression:
+		left := Eval(node.Left, env)
+		if isError(left) || isControl(left) {
+			return left
+		}
+		index := Eval(node.Index, env)
+		if isError(index) || isControl(index) {
+			return index
+		}
+		return evalIndexExpression(node, left, index)
+	case *ast.SliceExpression:
+		return evalSliceExpression(node, env)
+	}
+
+	return newError("unknown node: %T", node)
+}
+
+func evalProgram(program *ast.Program, env *object.Environment) object.Object {
+	var result object.Object
+
+	for _, stmt := range program.Statements {
+		result = Eval(stmt, env)
+
+		switch result := result.(type) {
+		case *object.ReturnValue:
+			return result.Value
+		case *object.Error:
+			return result
+		}
+	}
+
+	return result
+}
+
+// NOTE: keep ReturnValue wrapped so the enclosing function or program stops too,
+// break and continue likewise unwind to the enclosing loop
+func evalBlockStatement(block *ast.BlockStatement, env *object.Environment) object.Object {
+	var result object.Object
+
+	for _, stmt := range block.Statements {
+		result = Eval(stmt, env)
+
+		switch result.(type) {
+		case *object.ReturnValue, *object.Error, *object.Break, *object.Continue:
+			return result
+		}
+	}
+
+	// a block is used as a value, so empty blocks and trailing lets yield null
+	if result == nil {
+		return NULL
+	}
+	return result
+}
+
+func evalWhileStatement(stmt *ast.WhileStatement, env *object.Environment) object.Object {
+	for {
+		condition := Eval(stmt.Condition, env)
+		if isError(condition) || isControl(condition) {
+			return condition
+		}
+		if !isTruthy(condition) {
+			return nil
+		}
+
+		if result, done := evalLoopBody(stmt.Body, env); done {
+			return result
+		}
+	}
+}
+
+// like if and while bodies the loop runs in the enclosing scope, the variable
+// stays bound after the loop. arrays give their elements, hashes their keys
+// in insertion order and strings their characters
+func evalForStatement(stmt *ast.ForStatement, env *object.Environment) object.Object {
+	iterable := Eval(stmt.Iterable, env)
+	if isError(iterable) || isControl(iterable) {
+		return iterable
+	}
+
+	var items []object.Object
+	switch iterable := iterable.(type) {
+	case *object.Array:
+		items = append(items, iterable.Elements...)
+	case *object.Hash:
+		for _, pair := range iterable.Ordered() {
+			items = append(items, pair.Key)
+		}
+	case *object.String:
+		for _, r := range iterable.Value {
+			items = append(items, &object.String{Value: string(r)})
+		}
+	default:
+		return newErrorAt(stmt.Iterable, "not iterable: %s", iterable.Type())
+	}
+
+	for _, item := range items {
+		env.Set(stmt.Variable.Value, item)
+
+		if result, done := evalLoopBody(stmt.Body, env); done {
+			return result
+		}
+	}
+	return nil
+}
+
+// run one round, done when the loop has to stop with result
+func evalLoopBody(body *ast.BlockStatement, env *object.Environment) (object.Object, bool) {
+	switch result := Eval(body, env).(type) {
+	case *object.ReturnValue, *object.Error:
+		return result, true
+	case *object.Break:
+		return nil, true
+	default:
+		return nil, false
+	}
+}
+
+func evalIdentifier(node *ast.Identifier, env *object.Environment) object.Object {
+	if val, ok := env.Get(node.Value); ok {
+		return val
+	}
+	return newError("identifier not found: %s", node.Value)
+}
+
+func evalPrefixExpression(op string, right object.Object) object.Object {
+	switch op {
+	case "!":
+		return nativeBoolToBooleanObject(!isTruthy(right))
+	case "-":
+		switch right := right.(type) {
+		case *object.Integer:
+			return &object.Integer{Value: -right.Value}
+		case *object.Float:
+			return &object.Float{Value: -right.Value}
+		default:
+			return newError("unknown operator: -%s", right.Type())
+		}
+	default:
+		return newError("unknown operator: %s%s", op, right.Type())
+	}
+}
+
+func evalInfixExpression(op string, left, right object.Object) object.Object {
+	switch {
+	case left.Type() == object.INTEGER_OBJ && right.Type() == object.INTEGER_OBJ:
+		return evalIntegerInfixExpression(op, left.(*object.Integer), right.(*object.Integer))
+	case isNumber(left) && isNumber(right):
+		// an integer meets a float, compute in floats
+		return evalFloatInfixExpression(op, toFloat(left), toFloat(right))
+	case left.Type() == object.STRING_OBJ && right.Type() == object.STRING_OBJ:
+		return evalStringInfixExpression(op, left.(*object.String), right.(*object.String))
+	case left.Type() != right.Type():
+		return newError("type mismatch: %s %s %s", left.Type(), op, right.Type())
+	case op == "==":
+		return nativeBoolToBooleanObject(left == right)
+	case op == "!=":
+		return nativeBoolToBooleanObject(left != right)
+	default:
+		return newError("unknown operator: %s %s %s", left.Type(), op, right.Type())
+	}
+}
+
+// NOTE: the right side of && and || is only evaluated when it decides the result
+func evalLogicalExpression(node *ast.InfixExpression, left object.Object, env *object.Environment) object.Object {
+	if isTruthy(left) == (node.Token.Type == token.OR) {
+		return nativeBoolToBooleanObject(isTruthy(left))
+	}
+
+	right := Eval(node.Right, env)
+	if isError(right) || isControl(right) {
+		return right
+	}
+	return nativeBoolToBooleanObject(isTruthy(right))
+}
+
+func evalIntegerInfixExpression(op string, left, right *object.Integer) object.Object {
+	l, r := left.Value, right.Value
+
+	switch op {
+	case "+":
+		return &object.Integer{Value: l + r}
+	case "-":
+		return &object.Integer{Value: l - r}
+	case "*":
+		return &object.Integer{Value: l * r}
+	case "/":
+		if r == 0 {
+			return newError("division by zero")
+		}
+		return &object.Integer{Value: l / r}
+	case "<":
+		return nativeBoolToBooleanObject(l < r)
+	case "<=":
+		return nativeBoolToBooleanObject(l <= r)
+	case ">":
+		return nativeBoolToBooleanObject(l > r)
+	case ">=":
+		return nativeBoolToBooleanObject(l >= r)
+	case "==":
+		return nativeBoolToBooleanObject(l == r)
+	case "!=":
+		return nativeBoolToBooleanObject(l != r)
+	default:
+		return newError("unknown operator: %s %s %s", left.Type(), op, right.Type())
+	}
+}
+
+func evalFloatInfixExpression(op string, left, right *object.Float) object.Object {
+	l, r := left.Value, right.Value
+
+	switch op {
+	case "+":
+		return &object.Float{Value: l + r}
+	case "-":
+		return &object.Float{Value: l - r}
+	case "*":
+		return &object.Float{Value: l * r}
+	case "/":
+		if r == 0 {
+			return newError("division by zero")
+		}
+		return &object.Float{Value: l / r}
+	case "<":
+		return nativeBoolToBooleanObject(l < r)
+	case "<=":
+		return nativeBoolToBooleanObject(l <= r)
+	case ">":
+		return nativeBoolToBooleanObject(l > r)
+	case ">=":
+		return nativeBoolToBooleanObject(l >= r)
+	case "==":
+		return nativeBoolToBooleanObject(l == r)
+	case "!=":
+		return nativeBoolToBooleanObject(l != r)
+	default:
+		return newError("unknown operator: %s %s %s", left.Type(), op, right.Type())
+	}
+}
+
+func evalStringInfixExpression(op string, left, right *object.String) object.Object {
+	switch op {
+	case "+":
+		return &object.String{Value: left.Value + right.Value}
+	case "==":
+		return nativeBoolToBooleanObject(left.Value == right.Value)
+	case "!=":
+		return nativeBoolToBooleanObject(left.Value != right.Value)
+	default:
+		return newError("unknown operator: %s %s %s", left.Type(), op, right.Type())
+	}
+}
+
+// NOTE: x++ and x-- are pure, they yield x + 1 and x - 1 without rebinding x
+func evalSuffixExpression(op string, left object.Object) object.Object {
+	delta := 0
+	switch op {
+	case "++":
+		delta = 1
+	case "--":
+		delta = -1
+	}
+
+	switch left := left.(type) {
+	case *object.Integer:
+		if delta != 0 {
+			return &object.Integer{Value: left.Value + int64(delta)}
+		}
+	case *object.Float:
+		if delta != 0 {
+			return &object.Float{Value: left.Value + float64(delta)}
+		}
+	}
+	return newError("unknown operator: %s%s", left.Type(), op)
+}
+
+func evalIfExpression(expr *ast.IfExpreesion, env *object.Environment) object.Object {
+	condition := Eval(expr.Condition, env)
+	if isError(condition) || isControl(condition) {
+		return condition
+	}
+
+	if isTruthy(condition) {
+		return Eval(expr.Consequence, env)
+	} else if expr.Alternatvie != nil {
+		return Eval(expr.Alternatvie, env)
+	}
+	return NULL
+}
+
+func evalHashLiteral(node *ast.HashLiteral, env *object.Environment) object.Object {
+	hash := object.NewHash()
+
+	for i, keyNode := range node.Keys {
+		key := Eval(keyNode, env)
+		if isError(key) || isControl(key) {
+			return key
+		}
+		hashable, ok := key.(object.Hashable)
+		if !ok {
+			return newErrorAt(keyNode, "unusable as hash key: %s", key.Type())
+		}
+
+		value := Eval(node.Values[i], env)
+		if isError(value) || isControl(value) {
+			return value
+		}
+		hash.Set(hashable, value)
+	}
+
+	return hash
+}
+
+func evalIndexExpression(node *ast.IndexExpression, left, index object.Object) object.Object {
+	switch left := left.(type) {
+	case *object.Array:
+		return evalArrayIndexExpression(node, left, index)
+	case *object.Hash:
+		return evalHashIndexExpression(node, left, index)
+	default:
+		return newErrorAt(node, "index operator not supported: %s", left.Type())
+	}
+}
+
+// h[key] is null when key is missing
+func evalHashIndexExpression(node *ast.IndexExpression, hash *object.Hash, index object.Object) object.Object {
+	key, ok := index.(object.Hashable)
+	if !ok {
+		return newErrorAt(node.Index, "unusable as hash key: %s", index.Type())
+	}
+	if value, ok := hash.Get(key); ok {
+		return value
+	}
+	return NULL
+}
+
+// arr[i], a negative i counts from the end so arr[-1] is the last element
+func evalArrayIndexExpression(node *ast.IndexExpression, array *object.Array, index object.Object) object.Object {
+	position, err := arrayPosition(node, array, index)
+	if err != nil {
+		return err
+	}
+	return array.Elements[position]
+}
+
+// the element index points at, an error if it is not an integer in range
+func arrayPosition(node *ast.IndexExpression, array *object.Array, index object.Object) (int64, *object.Error) {
+	i, ok := index.(*object.Integer)
+	if !ok {
+		return 0, newErrorAt(node.Index, "index must be INTEGER, got %s", index.Type())
+	}
+
+	length := int64(len(array.Elements))
+	position := i.Value
+	if position < 0 {
+		position += length
+	}
+	if position < 0 || position >= length {
+		return 0, newErrorAt(node.Index, "index out of range: %d with length %d", i.Value, length)
+	}
+	return position, nil
+}
+
+// x = v, arr[i] = v or h[k] = v. the nearest binding of x is changed, and
+// arrays and hashes are changed in place. the result is the new value
+func evalAssignExpression(node *ast.AssignExpression, env *object.Environment) object.Object {
+	switch target := node.Target.(type) {
+	case *ast.Identifier:
+		current, ok := env.Get(target.Value)
+		if !ok {
+			return newErrorAt(target, "assignment to undeclared identifier: %s", target.Value)
+		}
+		value := evalAssignedValue(node, current, env)
+		if isError(value) || isControl(value) {
+			return value
+		}
+		env.Assign(target.Value, value)
+		return value
+	case *ast.IndexExpression:
+		return evalIndexAssignment(node, target, env)
+	default:
+		return newErrorAt(node.Target, "cannot assign to %s", node.Target.String())
+	}
+}
+
+func evalIndexAssignment(node *ast.AssignExpression, target *ast.IndexExpression, env *object.Environment) object.Object {
+	left := Eval(target.Left, env)
+	if isError(left) || isControl(left) {
+		return left
+	}
+	index := Eval(target.Index, env)
+	if isError(index) || isControl(index) {
+		return index
+	}
+
+	switch left := left.(type) {
+	case *object.Array:
+		position, err := arrayPosition(target, left, index)
+		if err != nil {
+			return err
+		}
+		value := evalAssignedValue(node, left.Elements[position], env)
+		if isError(value) || isControl(value) {
+			return value
+		}
+		left.Elements[position] = value
+		return value
+	case *object.Hash:
+		key, ok := index.(object.Hashable)
+		if !ok {
+			return newErrorAt(target.Index, "unusable as hash key: %s", index.Type())
+		}
+		current, ok := left.Get(key)
+		if !ok {
+			// h[k] = v adds a key, but h[k] += v needs one to add to
+			if node.Token.Type != token.ASSIGN {
+				return newErrorAt(target.Index, "key not found: %s", object.InspectKey(index))
+			}
+			current = NULL
+		}
+		value := evalAssignedValue(node, current, env)
+		if isError(value) || isControl(value) {
+			return value
+		}
+		left.Set(key, value)
+		return value
+	default:
+		return newErrorAt(target, "index assignment not supported: %s", left.Type())
+	}
+}
+
+// the right side, or for x += v and the like, x + v with the current value of x
+func evalAssignedValue(node *ast.AssignExpression, current object.Object, env *object.Environment) object.Object {
+	value := Eval(node.Value, env)
+	if isError(value) || isControl(value) || node.Token.Type == token.ASSIGN {
+		return value
+	}
+	result := evalInfixExpression(strings.TrimSuffix(node.TokenLiteral(), "="), current, value)
+	if err, ok := result.(*object.Error); ok {
+		err.Pos = node.Pos()
+	}
+	return result
+}
+
+// arr[low:high] is a new array of the elements from low up to but not
+// including high, the bounds default to 0 and len(arr) and may be negative
+func evalSliceExpression(node *ast.SliceExpression, env *object.Environment) object.Object {
+	left := Eval(node.Left, env)
+	if isError(left) || isControl(left) {
+		return left
+	}
+	array, ok := left.(*object.Array)
+	if !ok {
+		return newErrorAt(node, "slice operator not supported: %s", left.Type())
+	}
+
+	length := int64(len(array.Elements))
+	bounds := []int64{0, length}
+	// the bounds as written, for the error, an omitted one stays empty
+	written := []string{"", ""}
+	for i, bound := range []ast.Expression{node.Low, node.High} {
+		if bound == nil {
+			continue
+		}
+		value := Eval(bound, env)
+		if isError(value) || isControl(value) {
+			return value
+		}
+		integer, ok := value.(*object.Integer)
+		if !ok {
+			return newErrorAt(bound, "slice bound must be INTEGER, got %s", value.Type())
+		}
+		bounds[i] = integer.Value
+		written[i] = strconv.FormatInt(integer.Value, 10)
+		if bounds[i] < 0 {
+			bounds[i] += length
+		}
+	}
+
+	low, high := bounds[0], bounds[1]
+	if low < 0 || high > length || low > high {
+		return newErrorAt(node, "slice bounds out of range: [%s:%s] with length %d", written[0], written[1], length)
+	}
+	elements := make([]object.Object, high-low)
+	copy(elements, array.Elements[low:high])
+	return &object.Array{Elements: elements}
+}
+
+// evaluate left to right, stop at the first error and return only it
+func evalExpressions(exprs []ast.Expression, env *object.Environment) []object.Object {
+	result := []object.Object{}
+
+	for _, expr := range exprs {
+		evaluated := Eval(expr, env)
+		if isError(evaluated) || isControl(evaluated) {
+			return []object.Object{evaluated}
+		}
+		result = append(result, evaluated)
+	}
+
+	return result
+}
+
+func applyFunction(fn object.Object, args []object.Object) object.Object {
+	function, ok := fn.(*object.Function)
+	if !ok {
+		return newError("not a function: %s", fn.Type())
+	}
+	if len(args) != len(function.Parameters) {
+		return newError("wrong number of arguments: want=%d, got=%d",
+			len(function.Parameters), len(args))
+	}
+
+	env := object.NewEnclosedEnvironment(function.Env)
+	for i, param := range function.Parameters {
+		env.Set(param.Value, args[i])
+	}
+
+	evaluated := Eval(function.Body, env)
+	// NOTE: a return only leaves the function it is in
+	if returnValue, ok := evaluated.(*object.ReturnValue); ok {
+		return returnValue.Value
+	}
+	return evaluated
+}
+
+// some helper functions
+func nativeBoolToBooleanObject(b bool) *object.Boolean {
+	if b {
+		return TRUE
+	}
+	return FALSE
+}
+
+// null and false are falsy, everything else is truthy
+func isTruthy(obj object.Object) bool {
+	switch obj {
+	case NULL, FALSE:
+		return false
+	default:
+		return true
+	}
+}
+
+func isNumber(obj object.Object) bool {
+	return obj.Type() == object.INTEGER_OBJ || obj.Type() == object.FLOAT_OBJ
+}
+
+func toFloat(obj object.Object) *object.Float {
+	if integer, ok := obj.(*object.Integer); ok {
+		return &object.Float{Value: float64(integer.Value)}
+	}
+	return obj.(*object.Float)
+}
+
+func isError(obj object.Object) bool {
+	return obj != nil && obj.Type() == object.ERROR_OBJ
+}
+
+// return, break and continue unwind through any expression they come out of,
+// like `let x = if (c) { break; }`, up to the function or loop they leave
+func isControl(obj object.Object) bool {
+	switch obj.(type) {
+	case *object.ReturnValue, *object.Break, *object.Continue:
+		return true
+	default:
+		return false
+	}
+}
+
+func newError(format string, a ...interface{}) *object.Error {
+	return &object.Error{Message: fmt.Sprintf(format, a...)}
+}
+
+// an error that points at node in the source
+func newErrorAt(node ast.Node, format string, a ...interface{}) *object.Error {
+	err := newError(format, a...)
+	err.Pos = node.Pos()
+	return err
+}
@@ -0,0 +1,513 @@
+package evaluator
+
+import (
+	"compiler/lexer"
+	"compiler/object"
+	"compiler/parser"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testEval(t *testing.T, input string) object.Object {
+	l := lexer.New(input)
+	p := parser.New(l)
+	program := p.ParseProgram()
+	require.Empty(t, p.Errors())
+
+	return Eval(program, object.NewEnvironment())
+}
+
+func TestEvalIntegerExpression(t *testing.T) {
+	table := []struct {
+		input  string
+		expect int64
+	}{
+		{"5;", 5},
+		{"-5;", -5},
+		{"5 + 5 * 2;", 15},
+		{"-5 + 10;", 5},
+		{"(5 + 5) * 2;", 20},
+		{"20 / 2 - 3;", 7},
+		{"1++;", 2},
+		{"true ? 1 : undefined;", 1},
+		{"false ? undefined : 0 ? 2 : 3;", 2},
+		{"1--;", 0},
+		{"-(1 + 2)++;", -4},
+	}
+
+	for _, data := range table {
+		result := testEval(t, data.input)
+
+		integer, ok := result.(*object.Integer)
+		require.True(t, ok, "%s: got %T", data.input, result)
+		assert.Equal(t, data.expect, integer.Value, data.input)
+	}
+}
+
+func TestEvalFloatExpression(t *testing.T) {
+	table := []struct {
+		input  string
+		expect string
+	}{
+		{"3.5;", "3.5"},
+		{"-1.5;", "-1.5"},
+		{"0.5 + 0.25;", "0.75"},
+		{"1 + 0.5;", "1.5"},
+		{"3 / 2.0;", "1.5"},
+		{"2.0 * 3;", "6.0"},
+		{"1.5e3;", "1500.0"},
+		{"1.5++;", "2.5"},
+		{"1e100 * 1e300;", "+Inf"},
+	}
+
+	for _, data := range table {
+		result := testEval(t, data.input)
+
+		float, ok := result.(*object.Float)
+		require.True(t, ok, "%s: got %T", data.input, result)
+		assert.Equal(t, data.expect, float.Inspect(), data.input)
+	}
+}
+
+func TestEvalBooleanExpression(t *testing.T) {
+	table := []struct {
+		input  string
+		expect bool
+	}{
+		{"true;", true},
+		{"false;", false},
+		{"!true;", false},
+		{"!!5;", true},
+		{"1 < 2;", true},
+		{"1 >= 2;", false},
+		{"2 <= 2;", true},
+		{"1 == 1;", true},
+		{"true == false;", false},
+		{"(1 > 2) == false;", true},
+		{"1 < 2 && 2 < 3;", true},
+		{"1 < 2 && 3 < 2;", false},
+		{"false || 1;", true},
+		{"if (false) { 1 } || false;", false},
+		{"false && undefined;", false},
+		{"true || undefined;", true},
+		{"1 != 2;", true},
+		{"\"a\" != \"a\";", false},
+		{"true != false;", true},
+		{"1 > 2 ? false : true;", true},
+		{"1 == 1.0;", true},
+		{"0.5 < 1;", true},
+		{"2.5 >= 2.5;", true},
+	}
+
+	for _, data := range table {
+		result := testEval(t, data.input)
+
+		boolean, ok := result.(*object.Boolean)
+		require.True(t, ok, "%s: got %T", data.input, result)
+		assert.Equal(t, data.expect, boolean.Value, data.input)
+	}
+}
+
+func TestEvalIfExpression(t *testing.T) {
+	table := []struct {
+		input  string
+		expect interface{}
+	}{
+		{"if (true) { 10 }", int64(10)},
+		{"if (false) { 10 }", nil},
+		{"if (1 < 2) { 10 } else { 20 }", int64(10)},
+		{"if (1 > 2) { 10 } else { 20 }", int64(20)},
+		{"if (1) { }", nil},
+	}
+
+	for _, data := range table {
+		result := testEval(t, data.input)
+
+		if data.expect == nil {
+			assert.Equal(t, NULL, result, data.input)
+			continue
+		}
+		integer, ok := result.(*object.Integer)
+		require.True(t, ok, "%s: got %T", data.input, result)
+		assert.Equal(t, data.expect, integer.Value, data.input)
+	}
+}
+
+func TestEvalError(t *testing.T) {
+	table := []struct {
+		input  string
+		expect string
+	}{
+		{"5 + true;", "type mismatch: INTEGER + BOOLEAN"},
+		{"-true;", "unknown operator: -BOOLEAN"},
+		{"true + false;", "unknown operator: BOOLEAN + BOOLEAN"},
+		{"true++;", "unknown operator: BOOLEAN++"},
+		{"1 / 0;", "division by zero"},
+		{"1.5 / 0;", "division by zero"},
+		{"-1.5 + true;", "type mismatch: FLOAT + BOOLEAN"},
+		{"\"a\" * 1.5;", "type mismatch: STRING * FLOAT"},
+		{"foobar;", "identifier not found: foobar"},
+		{"true && foobar;", "identifier not found: foobar"},
+		{"if (10 > 1) { true + false; 10 }", "unknown operator: BOOLEAN + BOOLEAN"},
+	}
+
+	for _, data := range table {
+		result := testEval(t, data.input)
+
+		err, ok := result.(*object.Error)
+		require.True(t, ok, "%s: got %T", data.input, result)
+		assert.Equal(t, data.expect, err.Message, data.input)
+	}
+}
+
+func TestEnvironment(t *testing.T) {
+	outer := object.NewEnvironment()
+	outer.Set("x", &object.Integer{Value: 1})
+	inner := object.NewEnclosedEnvironment(outer)
+	inner.Set("y", &object.Integer{Value: 2})
+
+	x, ok := inner.Get("x")
+	require.True(t, ok)
+	assert.Equal(t, "1", x.Inspect())
+
+	_, ok = outer.Get("y")
+	assert.False(t, ok)
+
+	inner.Set("x", &object.Integer{Value: 3})
+	x, _ = outer.Get("x")
+	assert.Equal(t, "1", x.Inspect())
+
+	assert.True(t, inner.Assign("y", &object.Integer{Value: 4}))
+	assert.True(t, object.NewEnclosedEnvironment(outer).Assign("x", &object.Integer{Value: 5}))
+	x, _ = outer.Get("x")
+	assert.Equal(t, "5", x.Inspect())
+	assert.False(t, inner.Assign("z", &object.Integer{Value: 6}))
+	_, ok = inner.Get("z")
+	assert.False(t, ok)
+}
+
+func TestEvalStringExpression(t *testing.T) {
+	table := []struct {
+		input  string
+		expect string
+	}{
+		{`"hello";`, "hello"},
+		{`"hello" + " " + "world";`, "hello world"},
+		{`"a\tb";`, "a\tb"},
+	}
+
+	for _, data := range table {
+		result := testEval(t, data.input)
+
+		str, ok := result.(*object.String)
+		require.True(t, ok, "%s: got %T", data.input, result)
+		assert.Equal(t, data.expect, str.Value, data.input)
+	}
+
+	assert.Equal(t, TRUE, testEval(t, `"a" == "a";`))
+	assert.Equal(t, FALSE, testEval(t, `"a" == "b";`))
+
+	err, ok := testEval(t, `"a" - "b";`).(*object.Error)
+	require.True(t, ok)
+	assert.Equal(t, "unknown operator: STRING - STRING", err.Message)
+}
+
+func TestEvalArrayExpression(t *testing.T) {
+	table := []struct {
+		input  string
+		expect string
+	}{
+		{"[];", "[]"},
+		{"[1, 2 * 3, \"a\", [true]];", "[1, 6, a, [true]]"},
+		{"[1, 2, 3][0];", "1"},
+		{"[1, 2, 3][1 + 1];", "3"},
+		{"let a = [1, 2, 3]; a[-1] + a[-3];", "4"},
+		{"[[1, 2], [3]][0][1];", "2"},
+		{"let a = [1, 2, 3, 4]; a[1:3];", "[2, 3]"},
+		{"let a = [1, 2, 3, 4]; a[:2];", "[1, 2]"},
+		{"let a = [1, 2, 3, 4]; a[-2:];", "[3, 4]"},
+		{"let a = [1, 2, 3, 4]; a[:];", "[1, 2, 3, 4]"},
+		{"let a = [1, 2, 3, 4]; a[2:2];", "[]"},
+	}
+
+	for _, data := range table {
+		result := testEval(t, data.input)
+		require.False(t, isError(result), "%s: got %s", data.input, result.Inspect())
+		assert.Equal(t, data.expect, result.Inspect(), data.input)
+	}
+
+	table = []struct {
+		input  string
+		expect string
+	}{
+		{"[1, 2, 3][3];", "ERROR: 1:11: index out of range: 3 with length 3"},
+		{"let a = [1];\na[-2];", "ERROR: 2:3: index out of range: -2 with length 1"},
+		{"[1][true];", "ERROR: 1:5: index must be INTEGER, got BOOLEAN"},
+		{"1[0];", "ERROR: 1:1: index operator not supported: INTEGER"},
+		{"[1, 2][1:3];", "ERROR: 1:1: slice bounds out of range: [1:3] with length 2"},
+		{"[1, 2][2:1];", "ERROR: 1:1: slice bounds out of range: [2:1] with length 2"},
+		{"let a = [1, 2, 3]; a[-10:1];", "ERROR: 1:20: slice bounds out of range: [-10:1] with length 3"},
+		{"[1, 2][3:];", "ERROR: 1:1: slice bounds out of range: [3:] with length 2"},
+		{"[1, 2][:-3];", "ERROR: 1:1: slice bounds out of range: [:-3] with length 2"},
+		{"[1, 2][\"a\":];", "ERROR: 1:8: slice bound must be INTEGER, got STRING"},
+		{"[1, foo][0];", "ERROR: identifier not found: foo"},
+	}
+
+	for _, data := range table {
+		result := testEval(t, data.input)
+		require.True(t, isError(result), "%s: got %T", data.input, result)
+		assert.Equal(t, data.expect, result.Inspect(), data.input)
+	}
+}
+
+func TestEvalHashExpression(t *testing.T) {
+	table := []struct {
+		input  string
+		expect string
+	}{
+		{"{};", "{}"},
+		{`{"a": 1, 2: true, false: "no"};`, `{"a": 1, 2: true, false: no}`},
+		{`{"2": 1, 2: 2};`, `{"2": 1, 2: 2}`},
+		{`let k = "b"; {"a": 1, k: 2, "a": 3};`, `{"a": 3, "b": 2}`},
+		{`{"one": 1, "two": 2}["two"];`, "2"},
+		{`let h = {1: "int", true: "bool", "1": "string"}; h[1] + h[true] + h["1"];`, "intboolstring"},
+		{`{"a": 1}["b"];`, "null"},
+		{`{"a": {"b": [1, 2]}}["a"]["b"][-1];`, "2"},
+		{`let h = {"a" + "b": 1 + 1}; h["ab"];`, "2"},
+	}
+
+	for _, data := range table {
+		result := testEval(t, data.input)
+		require.False(t, isError(result), "%s: got %s", data.input, result.Inspect())
+		assert.Equal(t, data.expect, result.Inspect(), data.input)
+	}
+
+	table = []struct {
+		input  string
+		expect string
+	}{
+		{`{fn(x) { x }: 1};`, "ERROR: 1:2: unusable as hash key: FUNCTION"},
+		{`{"a": 1}[[1]];`, "ERROR: 1:10: unusable as hash key: ARRAY"},
+		{`{1.5: 1};`, "ERROR: 1:2: unusable as hash key: FLOAT"},
+		{`{"a": 1}[0:1];`, "ERROR: 1:1: slice operator not supported: HASH"},
+	}
+
+	for _, data := range table {
+		result := testEval(t, data.input)
+		require.True(t, isError(result), "%s: got %T", data.input, result)
+		assert.Equal(t, data.expect, result.Inspect(), data.input)
+	}
+}
+
+func TestHashKey(t *testing.T) {
+	hello1 := &object.String{Value: "Hello World"}
+	hello2 := &object.String{Value: "Hello World"}
+	other := &object.String{Value: "My name is johnny"}
+
+	assert.Equal(t, hello1.HashKey(), hello2.HashKey())
+	assert.NotEqual(t, hello1.HashKey(), other.HashKey())
+	// the same in every run
+	assert.Equal(t, uint64(0x3d58dee72d4e0c27), hello1.HashKey().Value)
+	assert.NotEqual(t, (&object.Integer{Value: 1}).HashKey(), TRUE.HashKey())
+}
+
+func TestEvalFnExpression(t *testing.T) {
+	result := testEval(t, "fn(x) { x + 2; };")
+
+	fn, ok := result.(*object.Function)
+	require.True(t, ok, "got %T", result)
+	require.Equal(t, 1, len(fn.Parameters))
+	assert.Equal(t, "x", fn.Parameters[0].String())
+	assert.Equal(t, "{(x + 2)}", fn.Body.String())
+	assert.Equal(t, "fn(x) {(x + 2)}", fn.Inspect())
+}
+
+func TestEvalCallExpression(t *testing.T) {
+	table := []struct {
+		input  string
+		expect int64
+	}{
+		{"fn(x) { x; }(5)", 5},
+		{"fn(x) { return x * 2; 0 }(5)", 10},
+		{"fn(x, y) { x + y; }(5, 5 * 2)", 15},
+		{"fn(x) { fn(y) { x + y } }(1)(2)", 3},
+		{"fn(f) { f(3) }(fn(x) { x * x })", 9},
+		{"fn() { if (true) { 1 } else { 2 } }()", 1},
+		{"fn() { if (true) { return 1; } 2 }()", 1},
+		{"let add = fn(a, b) { a + b }; add(add(1, 2), 3)", 6},
+	}
+
+	for _, data := range table {
+		result := testEval(t, data.input)
+
+		integer, ok := result.(*object.Integer)
+		require.True(t, ok, "%s: got %T", data.input, result)
+		assert.Equal(t, data.expect, integer.Value, data.input)
+	}
+
+	errors := []struct {
+		input  string
+		expect string
+	}{
+		{"5(1)", "not a function: INTEGER"},
+		{"fn(x) { x }()", "wrong number of arguments: want=1, got=0"},
+		{"fn(x) { x }(y)", "identifier not found: y"},
+	}
+
+	for _, data := range errors {
+		err, ok := testEval(t, data.input).(*object.Error)
+		require.True(t, ok, data.input)
+		assert.Equal(t, data.expect, err.Message, data.input)
+	}
+}
+
+func TestEvalLetStatement(t *testing.T) {
+	table := []struct {
+		input  string
+		expect int64
+	}{
+		{"let a = 5; a;", 5},
+		{"let a = 5 * 5; a", 25},
+		{"let a = 5; let b = a; let c = a + b + 5; c;", 15},
+		{"let x = 1; let f = fn() { let x = 2; x }; f() * 10 + x", 21},
+		{"let adder = fn(x) { fn(y) { x + y } }; let addTwo = adder(2); addTwo(3)", 5},
+	}
+
+	for _, data := range table {
+		result := testEval(t, data.input)
+
+		integer, ok := result.(*object.Integer)
+		require.True(t, ok, "%s: got %T", data.input, result)
+		assert.Equal(t, data.expect, integer.Value, data.input)
+	}
+}
+
+func TestEvalReturnStatement(t *testing.T) {
+	table := []struct {
+		input  string
+		expect int64
+	}{
+		{"return 10;", 10},
+		{"return 10; 9;", 10},
+		{"9; return 2 * 5; 9;", 10},
+		{"if (10 > 1) { if (10 > 1) { return 10; } return 1; }", 10},
+		{"let f = fn() { let x = if (true) { return 5 }; 9 }; f();", 5},
+		{"let f = fn() { -if (true) { return 6 } }; f();", 6},
+		{"let f = fn(a) { a }; let g = fn() { f(if (true) { return 7 }) + 1 }; g();", 7},
+		{"let g = fn() { [1, 2][if (true) { return 8 }] }; g() * 2;", 16},
+		{"let x = if (true) { return 10 }; 9", 10},
+	}
+
+	for _, data := range table {
+		result := testEval(t, data.input)
+
+		integer, ok := result.(*object.Integer)
+		require.True(t, ok, "%s: got %T", data.input, result)
+		assert.Equal(t, data.expect, integer.Value, data.input)
+	}
+
+	assert.Equal(t, NULL, testEval(t, "return; 1"))
+}
+
+func TestEvalLoopStatement(t *testing.T) {
+	table := []struct {
+		input  string
+		expect string
+	}{
+		{"let i = [0]; while (i[0] < 3) { let i = [i[0] + 1]; } i;", "[3]"},
+		{"let f = fn(n) { let acc = [0, n]; while (acc[1] > 0) { let acc = [acc[0] + acc[1], acc[1] - 1]; } acc[0] }; f(4);", "10"},
+		{"let sum = fn(arr) { let s = 0; for (x in arr) { let s = s + x; } s }; sum([1, 2, 3]);", "6"},
+		{"let s = \"\"; for (k in {\"b\": 1, \"a\": 2}) { let s = s + k; } s;", "ba"},
+		{"let s = \"\"; for (c in \"héllo\") { if (c == \"l\") { continue; } let s = s + c; } s;", "héo"},
+		{"let n = 0; while (true) { let n = n + 1; if (n == 5) { break; } } n;", "5"},
+		{"let first = fn(arr) { for (x in arr) { if (x > 1) { return x; } } -1 }; first([1, 5, 7]);", "5"},
+		{"let find = fn() { while (true) { for (x in [1, 2]) { if (x == 2) { return x * 10; } } } }; find();", "20"},
+		{"let n = 0; for (x in [1, 2, 3]) { for (y in [1, 2, 3]) { if (y == 2) { break; } let n = n + 1; } } n;", "3"},
+		{"let fs = []; for (x in [1, 2]) { let fs = fs[:] } fs;", "[]"},
+		{"let v = 0; for (x in []) { let v = 1 } v;", "0"},
+		{"for (x in [1, 2]) { } x;", "2"},
+		{"while (false) { 1 }", "null"},
+		{"let n = 0; while (n < 3) { let x = if (true) { break; }; let n = n + 1; } n;", "0"},
+		{"let n = 0; let s = 0; while (n < 3) { let n = n + 1; if (true) { continue } + 1; let s = s + 1; } s;", "0"},
+		{"let s = 0; for (x in [1, 2, 3]) { let s = s + [x, if (x == 2) { continue; }][0]; } s;", "4"},
+		{"let n = 0; while (true) { let n = n + 1; let h = {\"k\": n == 2 ? if (true) { break; } : n}; } n;", "2"},
+	}
+
+	for _, data := range table {
+		result := testEval(t, data.input)
+		if result == nil {
+			result = NULL
+		}
+		require.False(t, isError(result), "%s: got %s", data.input, result.Inspect())
+		assert.Equal(t, data.expect, result.Inspect(), data.input)
+	}
+
+	result := testEval(t, "for (x in 5) { x }")
+	require.True(t, isError(result))
+	assert.Equal(t, "ERROR: 1:11: not iterable: INTEGER", result.Inspect())
+
+	result = testEval(t, "while (x) { 1 }")
+	require.True(t, isError(result))
+	assert.Equal(t, "identifier not found: x", result.(*object.Error).Message)
+}
+
+func TestEvalAssignExpression(t *testing.T) {
+	table := []struct {
+		input  string
+		expect string
+	}{
+		{"let x = 1; x = x + 1; x;", "2"},
+		{"let x = 1; x += 2;", "3"},
+		{"let x = 10; x -= 2; x *= 3; x /= 4; x;", "6"},
+		{"let x = 1.5; x += 1; x;", "2.5"},
+		{"let s = \"a\"; s += \"b\"; s;", "ab"},
+		{"let a = 1; let b = 2; a = b = 5; [a, b];", "[5, 5]"},
+		{"let arr = [1, 2, 3]; arr[0] = 9; arr[-1] += 10; arr;", "[9, 2, 13]"},
+		{"let h = {\"k\": 3}; h[\"k\"] -= 1; h[\"n\"] = true; h;", "{\"k\": 2, \"n\": true}"},
+		{"let m = [[1], [2]]; m[1][0] *= 5; m;", "[[1], [10]]"},
+		{"let a = [1]; let b = a; b[0] = 2; a;", "[2]"},
+		{"let a = [1]; let b = a[:]; b[0] = 2; a;", "[1]"},
+		{"let a = [1]; a[0] = a; a;", "[[...]]"},
+		{"let h = {}; h[\"self\"] = h; h[1] = [h]; h;", "{\"self\": {...}, 1: [{...}]}"},
+		{"let a = [1]; let h = {\"a\": a}; a[0] = h; a;", "[{\"a\": [...]}]"},
+		{"let x = [1]; [x, x];", "[[1], [1]]"},
+		{"let n = 0; let inc = fn() { n += 1 }; inc(); inc(); n;", "2"},
+		{"let counter = fn() { let c = 0; fn() { c += 1 } }; let next = counter(); next(); next();", "2"},
+		{"let n = 1; let f = fn(n) { n = 5; n }; f(0) + n;", "6"},
+		{"let i = 0; let s = 0; while (i < 4) { i += 1; s += i; } s;", "10"},
+		{"let x = 0; if (true) { x = 7 } x;", "7"},
+		{"let x = 0; (x = 3) * 2;", "6"},
+	}
+
+	for _, data := range table {
+		result := testEval(t, data.input)
+		require.False(t, isError(result), "%s: got %s", data.input, result.Inspect())
+		assert.Equal(t, data.expect, result.Inspect(), data.input)
+	}
+
+	errors := []struct {
+		input  string
+		expect string
+	}{
+		{"x = 1;", "ERROR: 1:1: assignment to undeclared identifier: x"},
+		{"let f = fn() { y += 1 }; f();", "ERROR: 1:16: assignment to undeclared identifier: y"},
+		{"let a = [1]; a[1] = 2;", "ERROR: 1:16: index out of range: 1 with length 1"},
+		{"let a = [1]; a[\"0\"] = 2;", "ERROR: 1:16: index must be INTEGER, got STRING"},
+		{"let h = {}; h[[]] = 1;", "ERROR: 1:15: unusable as hash key: ARRAY"},
+		{"let h = {}; h[\"k\"] -= 1;", "ERROR: 1:15: key not found: \"k\""},
+		{"let h = {\"1\": 1}; h[1] += 1;", "ERROR: 1:21: key not found: 1"},
+		{"let h = {1: 2}; h[1] /= 0;", "ERROR: 1:17: division by zero"},
+		{"let s = \"ab\"; s[0] = \"c\";", "ERROR: 1:15: index assignment not supported: STRING"},
+		{"let x = 1; x += true;", "ERROR: 1:12: type mismatch: INTEGER + BOOLEAN"},
+		{"let a = [\"s\"]; a[0] -= 1;", "ERROR: 1:16: type mismatch: STRING - INTEGER"},
+		{"let x = 1; x = y; x;", "ERROR: identifier not found: y"},
+	}
+
+	for _, data := range errors {
+		result := testEval(t, data.input)
+		require.True(t, isError(result), "%s: got %s", data.input, result.Inspect())
+		assert.Equal(t, data.expect, result.Inspect(), data.input)
+	}
+}
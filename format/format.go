@@ -0,0 +1,376 @@
+package format
+
+import (
+	"compiler/ast"
+	"compiler/diagnostic"
+	"compiler/lexer"
+	"compiler/parser"
+	"compiler/token"
+	"math"
+	"strings"
+)
+
+// one level of indentation
+const indentUnit = "    "
+
+// literals, identifiers, if and fn never need parentheses
+const primary = math.MaxInt
+
+// format src in the canonical style, comments are kept. src that does not
+// parse is left alone and its diagnostics are returned instead
+func Source(filename, src string) (string, []*diagnostic.Diagnostic) {
+	p := parser.New(lexer.New(src, lexer.WithFilename(filename)))
+	program := p.ParseProgram()
+	if diags := p.Diagnostics(); len(diags) != 0 {
+		return "", diags
+	}
+	return Program(program, Comments(src)), nil
+}
+
+// COMMENT tokens of src in source order
+func Comments(src string) []token.Token {
+	comments := []token.Token{}
+	l := lexer.New(src, lexer.WithComments())
+	for tok := l.NextToken(); tok.Type != token.EOF; tok = l.NextToken() {
+		if tok.Type == token.COMMENT {
+			comments = append(comments, tok)
+		}
+	}
+	return comments
+}
+
+// print program with comments put back near the statements they were next to.
+// formatting the result again gives the same text
+func Program(program *ast.Program, comments []token.Token) string {
+	p := &printer{comments: comments, first: true}
+	p.statements(program.Statements)
+	p.leadingComments(token.Position{Offset: math.MaxInt})
+	if p.sb.Len() != 0 {
+		p.sb.WriteString("\n")
+	}
+	return p.sb.String()
+}
+
+type printer struct {
+	sb     strings.Builder
+	indent int
+	// indentation is written lazily, so blank lines stay empty
+	pending bool
+
+	// comments not printed yet, in source order
+	comments []token.Token
+	// source line of what was printed last, to keep blank lines between statements
+	lastLine int
+	// no blank line before the first statement of a block
+	first bool
+	// a line comment runs to the end of the line, nothing may follow it
+	afterLineComment bool
+}
+
+func (p *printer) write(s string) {
+	if p.pending {
+		p.sb.WriteString(strings.Repeat(indentUnit, p.indent))
+		p.pending = false
+	}
+	p.sb.WriteString(s)
+	p.afterLineComment = false
+}
+
+func (p *printer) newline() {
+	p.sb.WriteString("\n")
+	p.pending = true
+	p.afterLineComment = false
+}
+
+// start a new line for something written at line in the source,
+// keeping at most one blank line before it
+func (p *printer) beginLine(line int) {
+	if p.sb.Len() != 0 {
+		p.newline()
+		if !p.first && line > p.lastLine+1 {
+			p.newline()
+		}
+	}
+	p.first = false
+}
+
+func (p *printer) statements(stmts []ast.Statement) {
+	for i, stmt := range stmts {
+		p.leadingComments(stmt.Pos())
+		p.beginLine(stmt.Pos().Line)
+		p.statement(stmt)
+
+		var next ast.Statement
+		if i+1 < len(stmts) {
+			next = stmts[i+1]
+		}
+		if needsSemicolon(stmt, next) {
+			p.write(";")
+		}
+		p.lastLine = stmt.End().Line
+		p.first = false
+		p.trailingComments(stmt.End())
+	}
+}
+
+func (p *printer) statement(stmt ast.Statement) {
+	switch stmt := stmt.(type) {
+	case *ast.LetStatement:
+		p.write("let " + stmt.Name.Value + " = ")
+		p.expression(stmt.Value)
+	case *ast.ReturnStatement:
+		p.write("return")
+		if stmt.Value != nil {
+			p.write(" ")
+			p.expression(stmt.Value)
+		}
+	case *ast.ExpressionStatement:
+		p.expression(stmt.Expression)
+	case *ast.BlockStatement:
+		p.block(stmt)
+	case *ast.WhileStatement:
+		p.write("while (")
+		p.expression(stmt.Condition)
+		p.write(") ")
+		p.block(stmt.Body)
+	case *ast.ForStatement:
+		p.write("for (" + stmt.Variable.Value + " in ")
+		p.expression(stmt.Iterable)
+		p.write(") ")
+		p.block(stmt.Body)
+	case *ast.BreakStatement:
+		p.write("break")
+	case *ast.ContinueStatement:
+		p.write("continue")
+	default:
+		p.write(stmt.String())
+	}
+}
+
+// every statement ends with ; except loops and an if, which only needs one
+// when the next statement would otherwise continue it, like if (a) {} (b)
+func needsSemicolon(stmt, next ast.Statement) bool {
+	switch stmt.(type) {
+	case *ast.WhileStatement, *ast.ForStatement:
+		return false
+	}
+	es, ok := stmt.(*ast.ExpressionStatement)
+	if !ok {
+		return true
+	}
+	if _, ok := es.Expression.(*ast.IfExpreesion); !ok {
+		return true
+	}
+	nextEs, ok := next.(*ast.ExpressionStatement)
+	if !ok {
+		return false
+	}
+
+	// look at the statement as printed, (b) loses its parentheses
+	printed := &printer{}
+	printed.expression(nextEs.Expression)
+	first := lexer.New(printed.sb.String()).NextToken()
+	return parser.Precedence(first.Type) > parser.LOWEST
+}
+
+func (p *printer) block(block *ast.BlockStatement) {
+	p.write("{")
+	start := p.sb.Len()
+	p.indent++
+	p.first = true
+	p.lastLine = block.Token.Pos.Line
+
+	p.statements(block.Statements)
+	p.leadingComments(block.Rbrace.Pos)
+
+	p.indent--
+	if p.sb.Len() != start {
+		p.newline()
+	}
+	p.write("}")
+	p.first = false
+	p.lastLine = block.Rbrace.Pos.Line
+}
+
+func (p *printer) expression(expr ast.Expression) {
+	switch expr := expr.(type) {
+	case *ast.Identifier:
+		p.write(expr.Value)
+	case *ast.IntegerLiteral:
+		p.write(expr.Token.Literal)
+	case *ast.FloatLiteral:
+		p.write(expr.Token.Literal)
+	case *ast.StringLiteral:
+		p.write(expr.Token.Literal)
+	case *ast.Boolean:
+		p.write(expr.Token.Literal)
+	case *ast.PrefixExpression:
+		p.write(expr.Token.Literal)
+		// -(-x) must not become --x
+		right, ok := expr.Right.(*ast.PrefixExpression)
+		nested := ok && expr.Token.Type == token.MINUS && right.Token.Type == token.MINUS
+		p.operand(expr.Right, nested || precedenceOf(expr.Right) < parser.PREFIX)
+	case *ast.InfixExpression:
+		// operators are left associative, so a right operand of the same
+		// precedence keeps its parentheses
+		precedence := parser.Precedence(expr.Token.Type)
+		p.operand(expr.Left, precedenceOf(expr.Left) < precedence)
+		p.write(" " + expr.Token.Literal + " ")
+		p.operand(expr.Right, precedenceOf(expr.Right) <= precedence)
+	case *ast.SuffixExpression:
+		p.operand(expr.Left, precedenceOf(expr.Left) < parser.SUFFIX)
+		p.write(expr.Token.Literal)
+	case *ast.ConditionalExpression:
+		// right associative, a conditional only needs parentheses as the condition
+		p.operand(expr.Condition, precedenceOf(expr.Condition) <= parser.CONDITIONAL)
+		p.write(" ? ")
+		p.expression(expr.Consequence)
+		p.write(" : ")
+		p.operand(expr.Alternative, precedenceOf(expr.Alternative) < parser.CONDITIONAL)
+	case *ast.AssignExpression:
+		// right associative, a = b = 1 needs no parentheses
+		p.operand(expr.Target, precedenceOf(expr.Target) <= parser.ASSIGN)
+		p.write(" " + expr.Token.Literal + " ")
+		p.operand(expr.Value, precedenceOf(expr.Value) < parser.ASSIGN)
+	case *ast.IfExpreesion:
+		p.write("if (")
+		p.expression(expr.Condition)
+		p.write(") ")
+		p.block(expr.Consequence)
+		if expr.Alternatvie != nil {
+			p.write(" else ")
+			p.block(expr.Alternatvie)
+		}
+	case *ast.FnExpression:
+		params := []string{}
+		for _, param := range expr.Param {
+			params = append(params, param.Value)
+		}
+		p.write("fn(" + strings.Join(params, ", ") + ") ")
+		p.block(&expr.Body)
+	case *ast.ArrayLiteral:
+		p.write("[")
+		p.expressionList(expr.Elements)
+		p.write("]")
+	case *ast.HashLiteral:
+		p.write("{")
+		for i, key := range expr.Keys {
+			if i != 0 {
+				p.write(", ")
+			}
+			p.expression(key)
+			p.write(": ")
+			p.expression(expr.Values[i])
+		}
+		p.write("}")
+	case *ast.IndexExpression:
+		// calls and indexes chain left to right, f(1)[2] needs no parentheses
+		p.operand(expr.Left, precedenceOf(expr.Left) < parser.CALL)
+		p.write("[")
+		p.expression(expr.Index)
+		p.write("]")
+	case *ast.SliceExpression:
+		p.operand(expr.Left, precedenceOf(expr.Left) < parser.CALL)
+		p.write("[")
+		if expr.Low != nil {
+			p.expression(expr.Low)
+		}
+		p.write(":")
+		if expr.High != nil {
+			p.expression(expr.High)
+		}
+		p.write("]")
+	case *ast.CallExpression:
+		p.operand(expr.Function, precedenceOf(expr.Function) < parser.CALL)
+		p.write("(")
+		p.expressionList(expr.Arguments)
+		p.write(")")
+	default:
+		p.write(expr.String())
+	}
+}
+
+func (p *printer) operand(expr ast.Expression, parens bool) {
+	if parens {
+		p.write("(")
+	}
+	p.expression(expr)
+	if parens {
+		p.write(")")
+	}
+}
+
+func (p *printer) expressionList(exprs []ast.Expression) {
+	for i, expr := range exprs {
+		if i != 0 {
+			p.write(", ")
+		}
+		p.expression(expr)
+	}
+}
+
+// how tightly expr holds together, compared with the operator around it
+func precedenceOf(expr ast.Expression) int {
+	switch expr := expr.(type) {
+	case *ast.InfixExpression:
+		return parser.Precedence(expr.Token.Type)
+	case *ast.PrefixExpression:
+		return parser.PREFIX
+	case *ast.SuffixExpression:
+		return parser.SUFFIX
+	case *ast.CallExpression:
+		return parser.CALL
+	case *ast.IndexExpression, *ast.SliceExpression:
+		return parser.INDEX
+	case *ast.ConditionalExpression:
+		return parser.CONDITIONAL
+	case *ast.AssignExpression:
+		return parser.ASSIGN
+	default:
+		return primary
+	}
+}
+
+// comments before pos, each on a line of its own
+func (p *printer) leadingComments(pos token.Position) {
+	for len(p.comments) != 0 && p.comments[0].Pos.Offset < pos.Offset {
+		comment := p.comments[0]
+		p.comments = p.comments[1:]
+
+		p.beginLine(comment.Pos.Line)
+		p.comment(comment)
+	}
+}
+
+// comments inside a statement or after it on its last line, they all
+// move behind it. comments in nested blocks were printed already. once a
+// line comment is written the rest go on lines of their own, as they would
+// when the output is read back as leading comments
+func (p *printer) trailingComments(end token.Position) {
+	ownLine := false
+	for len(p.comments) != 0 {
+		comment := p.comments[0]
+		if comment.Pos.Offset >= end.Offset && comment.Pos.Line != end.Line {
+			return
+		}
+		p.comments = p.comments[1:]
+
+		ownLine = ownLine || p.afterLineComment
+		if ownLine {
+			p.newline()
+		} else {
+			p.write(" ")
+		}
+		p.comment(comment)
+	}
+}
+
+func (p *printer) comment(comment token.Token) {
+	if strings.HasPrefix(comment.Literal, "//") {
+		p.write(strings.TrimRight(comment.Literal, " \t\r"))
+		p.afterLineComment = true
+	} else {
+		p.write(comment.Literal)
+	}
+	p.lastLine = comment.End.Line
+}
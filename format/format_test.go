@@ -0,0 +1,95 @@
+package format
+
+import (
+	"compiler/lexer"
+	"compiler/parser"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSource(t *testing.T) {
+	table := []struct {
+		input    string
+		expected string
+	}{
+		{"", ""},
+		{"let x=5", "let x = 5;\n"},
+		{"return", "return;\n"},
+		// parentheses
+		{"((1 + 2)) * 3", "(1 + 2) * 3;\n"},
+		{"(a + b) + c; a + (b + c)", "a + b + c;\na + (b + c);\n"},
+		{"a * (b * c) - (d - e)", "a * (b * c) - (d - e);\n"},
+		{"(a * b) + (c * d)", "a * b + c * d;\n"},
+		{"(a && b) || (c == d); a && (b || c)", "a && b || c == d;\na && (b || c);\n"},
+		{"(a ? b : c) ? (d ? e : f) : (g ? h : i)", "(a ? b : c) ? d ? e : f : g ? h : i;\n"},
+		{"(a ? b : c) + 1; f((a != b))", "(a ? b : c) + 1;\nf(a != b);\n"},
+		{"x=(y=1); (x = 1) + 2; a[i]+=(b ? 1 : 2); c ? x = 1 : y", "x = y = 1;\n(x = 1) + 2;\na[i] += b ? 1 : 2;\nc ? x = 1 : y;\n"},
+		{"[1,(2+3)][(0)]; (-a)[1]; (a[1:2])[ : 1]; (f(1))[2]", "[1, 2 + 3][0];\n(-a)[1];\na[1:2][:1];\nf(1)[2];\n"},
+		{`{"a":1,  2 : {}}["a"]; ({})`, "{\"a\": 1, 2: {}}[\"a\"];\n{};\n"},
+		{
+			"while (i < 3) { if (i == 1) { break; } continue }\nfor (x in [1, 2]) { x }\n[1]",
+			"while (i < 3) {\n    if (i == 1) {\n        break;\n    }\n    continue;\n}\nfor (x in [1, 2]) {\n    x;\n}\n[1];\n",
+		},
+		{"if (a) { b }; [1]", "if (a) {\n    b;\n};\n[1];\n"},
+		{"(a < b) == (c > d)", "a < b == c > d;\n"},
+		{"-(-a); -(a + b); (-a) * b", "-(-a);\n-(a + b);\n-a * b;\n"},
+		{"!(a == b); !(!a)", "!(a == b);\n!!a;\n"},
+		{"(-a)++; -(a++); (a + b)--", "(-a)++;\n-a++;\n(a + b)--;\n"},
+		{"(f)(1, (2 + 3)); (f(1))(2)", "f(1, 2 + 3);\nf(1)(2);\n"},
+		{`"a\n" + "b"`, "\"a\\n\" + \"b\";\n"},
+		{"(1.5e-3 * 2) + 0.5", "1.5e-3 * 2 + 0.5;\n"},
+		{"0xFF + (0b1010 * 1_000)", "0xFF + 0b1010 * 1_000;\n"},
+		// blocks
+		{
+			"let max = fn(a, b) { if (a > b) { a } else { return b; } };",
+			"let max = fn(a, b) {\n    if (a > b) {\n        a;\n    } else {\n        return b;\n    }\n};\n",
+		},
+		{"let f = fn() {}", "let f = fn() {};\n"},
+		{"if (a) { b }; (c)", "if (a) {\n    b;\n}\nc;\n"},
+		{"if (a) { b }; -c; if (a) { b }; (f)(1)", "if (a) {\n    b;\n};\n-c;\nif (a) {\n    b;\n}\nf(1);\n"},
+		{"if (a) { b }; (-c)++", "if (a) {\n    b;\n};\n(-c)++;\n"},
+		{"if (a) { b }; c", "if (a) {\n    b;\n}\nc;\n"},
+		// blank lines
+		{"let a = 1;\n\n\n\nlet b = 2;\nlet c = 3;", "let a = 1;\n\nlet b = 2;\nlet c = 3;\n"},
+		{"fn() {\n\n  a\n\n  b\n\n}", "fn() {\n    a;\n\n    b;\n};\n"},
+		// comments
+		{"// only a comment", "// only a comment\n"},
+		{"// a\r\nlet x = 1;  // b\r\n", "// a\nlet x = 1; // b\n"},
+		{"let x = 1 /* a */ + 2; /* b */", "let x = 1 + 2; /* a */ /* b */\n"},
+		{"let x = 1 + // one\n  2 /* two */ /* three */;", "let x = 1 + 2; // one\n/* two */\n/* three */\n"},
+		{"f(1, // a\n2) // b", "f(1, 2); // a\n// b\n"},
+		{
+			"let f = fn() { // a\n  1\n  /* b */\n}\n\n// c\nf()",
+			"let f = fn() {\n    // a\n    1;\n    /* b */\n};\n\n// c\nf();\n",
+		},
+		{"fn() {\n  // todo\n}", "fn() {\n    // todo\n};\n"},
+	}
+
+	for _, tt := range table {
+		formatted, diags := Source("a.mk", tt.input)
+		assert.Empty(t, diags, tt.input)
+		assert.Equal(t, tt.expected, formatted, tt.input)
+
+		again, diags := Source("a.mk", formatted)
+		assert.Empty(t, diags, formatted)
+		assert.Equal(t, formatted, again, "not idempotent: %q", tt.input)
+
+		assert.Equal(t, parse(t, tt.input), parse(t, formatted), "meaning changed: %q", tt.input)
+	}
+}
+
+func TestSourceWithErrors(t *testing.T) {
+	formatted, diags := Source("a.mk", "let = 5;")
+
+	assert.Equal(t, "", formatted)
+	assert.Len(t, diags, 1)
+	assert.Equal(t, "a.mk:1:5: Expect IDENT, got =", diags[0].Error())
+}
+
+func parse(t *testing.T, input string) string {
+	p := parser.New(lexer.New(input))
+	program := p.ParseProgram()
+	assert.Empty(t, p.Errors(), input)
+	return program.String()
+}
@@ -3,12 +3,14 @@ module compiler
 go 1.18
 
 require (
-	github.com/golang/glog v1.0.0
+	github.com/peterh/liner v1.2.2
 	github.com/stretchr/testify v1.7.2
 )
 
 require (
 	github.com/davecgh/go-spew v1.1.0 // indirect
+	github.com/mattn/go-runewidth v0.0.3 // indirect
 	github.com/pmezard/go-difflib v1.0.0 // indirect
+	golang.org/x/sys v0.0.0-20211117180635-dee7805ff2e1 // indirect
 	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
@@ -0,0 +1,51 @@
+package lexer
+
+import (
+	"compiler/diagnostic"
+	"compiler/token"
+)
+
+func (l *Lexer) atComment() bool {
+	return l.ch == '/' && (l.peekRune(1) == "/" || l.peekRune(1) == "*")
+}
+
+// scan a // or /* */ comment, l.ch is the first /.
+// block comments nest, an unterminated one runs to EOF and is ILLEGAL
+func (l *Lexer) readComment() token.Token {
+	pos := l.curPosition()
+	beginPosition := l.position
+	tok := token.Token{Type: token.COMMENT}
+
+	if l.peekRune(1) == "/" {
+		for l.ch != '\n' && l.ch != 0 {
+			l.readRune()
+		}
+	} else {
+		depth := 0
+		for {
+			if l.ch == 0 {
+				tok.Type = token.ILLEGAL
+				l.addDiagnostic(diagnostic.Errorf(diagnostic.UnterminatedComment,
+					diagnostic.Span{Pos: pos, End: l.curPosition()}, "unterminated block comment").
+					WithNote("block comments nest, every /* needs its own */"))
+				break
+			}
+			if l.ch == '/' && l.peekRune(1) == "*" {
+				depth++
+				l.readRune()
+			} else if l.ch == '*' && l.peekRune(1) == "/" {
+				depth--
+				l.readRune()
+				if depth == 0 {
+					l.readRune()
+					break
+				}
+			}
+			l.readRune()
+		}
+	}
+
+	tok.Literal = string(l.input[beginPosition:l.position])
+	tok.Pos, tok.End = pos, l.curPosition()
+	return tok
+}
@@ -1,7 +1,9 @@
 package lexer
 
 import (
+	"compiler/diagnostic"
 	"compiler/token"
+	"unicode/utf8"
 )
 
 type Lexer struct {
@@ -9,20 +11,71 @@ type Lexer struct {
 	position     int
 	readPosition int // point to next position to be read
 	ch           rune
+
+	// location of ch in the source
+	filename string
+	offset   int
+	line     int
+	column   int
+
+	diagnostics []*diagnostic.Diagnostic
+
+	// return comments as COMMENT tokens instead of skipping them
+	scanComments bool
+	// attach whitespace and comments to tokens, see trivia.go
+	keepTrivia bool
 }
 
-func New(input string) *Lexer {
+type Option func(*Lexer)
+
+// file name recorded in the position of every token
+func WithFilename(filename string) Option {
+	return func(l *Lexer) {
+		l.filename = filename
+	}
+}
+
+// keep comments as COMMENT tokens, for tools that care about them
+func WithComments() Option {
+	return func(l *Lexer) {
+		l.scanComments = true
+	}
+}
+
+func New(input string, opts ...Option) *Lexer {
 	l := &Lexer{
-		input: []rune(input),
+		input:  []rune(input),
+		line:   1,
+		column: 1,
+	}
+	for _, opt := range opts {
+		opt(l)
 	}
 	l.readRune()
 	return l
 }
 
 func (l *Lexer) NextToken() token.Token {
-	var tok token.Token
+	if l.keepTrivia {
+		return l.nextTokenWithTrivia()
+	}
 
 	l.skipDelim()
+	for l.atComment() {
+		comment := l.readComment()
+		if l.scanComments || comment.Type == token.ILLEGAL {
+			return comment
+		}
+		l.skipDelim()
+	}
+	return l.scanToken()
+}
+
+// scan the token at l.ch, whitespace and comments are already consumed
+func (l *Lexer) scanToken() token.Token {
+	var tok token.Token
+
+	pos := l.curPosition()
 	switch l.ch {
 	case '=':
 		if l.peekRune(1) == "=" {
@@ -53,6 +106,12 @@ func (l *Lexer) NextToken() token.Token {
 				Type:    token.PLUSPLUS,
 				Literal: "++",
 			}
+		} else if l.peekRune(1) == "=" {
+			l.readRune()
+			tok = token.Token{
+				Type:    token.PLUS_ASSIGN,
+				Literal: "+=",
+			}
 		} else {
 			tok = newToken(token.PLUS, l.ch)
 		}
@@ -63,6 +122,12 @@ func (l *Lexer) NextToken() token.Token {
 				Type:    token.MINUSMINUS,
 				Literal: "--",
 			}
+		} else if l.peekRune(1) == "=" {
+			l.readRune()
+			tok = token.Token{
+				Type:    token.MINUS_ASSIGN,
+				Literal: "-=",
+			}
 		} else {
 			tok = newToken(token.MINUS, l.ch)
 		}
@@ -86,10 +151,44 @@ func (l *Lexer) NextToken() token.Token {
 		} else {
 			tok = newToken(token.LT, l.ch)
 		}
+	case '&':
+		if l.peekRune(1) != "&" {
+			return l.readIllegal(pos)
+		}
+		l.readRune()
+		tok = token.Token{
+			Type:    token.AND,
+			Literal: "&&",
+		}
+	case '|':
+		if l.peekRune(1) != "|" {
+			return l.readIllegal(pos)
+		}
+		l.readRune()
+		tok = token.Token{
+			Type:    token.OR,
+			Literal: "||",
+		}
 	case '/':
-		tok = newToken(token.DIVIDE, l.ch)
+		if l.peekRune(1) == "=" {
+			l.readRune()
+			tok = token.Token{
+				Type:    token.DIVIDE_ASSIGN,
+				Literal: "/=",
+			}
+		} else {
+			tok = newToken(token.DIVIDE, l.ch)
+		}
 	case '*':
-		tok = newToken(token.MULTI, l.ch)
+		if l.peekRune(1) == "=" {
+			l.readRune()
+			tok = token.Token{
+				Type:    token.MULTI_ASSIGN,
+				Literal: "*=",
+			}
+		} else {
+			tok = newToken(token.MULTI, l.ch)
+		}
 	case '(':
 		tok = newToken(token.LPAREN, l.ch)
 	case ')':
@@ -98,10 +197,29 @@ func (l *Lexer) NextToken() token.Token {
 		tok = newToken(token.LBRACE, l.ch)
 	case '}':
 		tok = newToken(token.RBRACE, l.ch)
+	case '[':
+		tok = newToken(token.LBRACKET, l.ch)
+	case ']':
+		tok = newToken(token.RBRACKET, l.ch)
 	case ',':
 		tok = newToken(token.COMMA, l.ch)
 	case ';':
 		tok = newToken(token.SEMICOLON, l.ch)
+	case ':':
+		tok = newToken(token.COLON, l.ch)
+	case '"':
+		literal, ok := l.readString(pos)
+		if !ok && l.ch != '"' {
+			// unterminated, do not swallow the newline or EOF
+			tok = token.Token{Type: token.ILLEGAL, Literal: literal}
+			tok.Pos, tok.End = pos, l.curPosition()
+			return tok
+		}
+		tok.Literal = literal
+		tok.Type = token.STRING
+		if !ok {
+			tok.Type = token.ILLEGAL
+		}
 	case 0:
 		tok.Literal = ""
 		tok.Type = token.EOF
@@ -109,20 +227,41 @@ func (l *Lexer) NextToken() token.Token {
 		if l.isLetter(l.ch) {
 			tok.Literal = l.readIdentifier()
 			tok.Type = token.LoopUpKeywords(tok.Literal)
+			tok.Pos, tok.End = pos, l.curPosition()
 			return tok
 		} else if l.isDigit(l.ch) {
-			tok.Type = token.INT
-			tok.Literal = l.readNumber()
+			tok.Type, tok.Literal = l.readNumber(pos)
+			tok.Pos, tok.End = pos, l.curPosition()
 			return tok
 		} else {
-			tok = newToken(token.ILLEGAL, l.ch)
+			return l.readIllegal(pos)
 		}
 	}
 
 	l.readRune()
+	tok.Pos, tok.End = pos, l.curPosition()
+	return tok
+}
+
+// a character that starts no token
+func (l *Lexer) readIllegal(pos token.Position) token.Token {
+	tok := newToken(token.ILLEGAL, l.ch)
+	l.readRune()
+	tok.Pos, tok.End = pos, l.curPosition()
+	l.addDiagnostic(diagnostic.Errorf(diagnostic.IllegalCharacter,
+		diagnostic.TokenSpan(tok), "illegal character %q", tok.Literal))
 	return tok
 }
 
+// problems found while scanning, each is also returned as an ILLEGAL token
+func (l *Lexer) Diagnostics() []*diagnostic.Diagnostic {
+	return l.diagnostics
+}
+
+func (l *Lexer) addDiagnostic(d *diagnostic.Diagnostic) {
+	l.diagnostics = append(l.diagnostics, d)
+}
+
 func newToken(tokenType token.TokenType, ch rune) token.Token {
 	return token.Token{
 		Type:    tokenType,
@@ -146,20 +285,10 @@ func (l *Lexer) readIdentifier() string {
 	return string(l.input[beginPosition:l.position])
 }
 
-func (l *Lexer) readNumber() string {
-	beginPosition := l.position
-	for l.isDigit(l.ch) {
-		l.readRune()
-	}
-	return string(l.input[beginPosition:l.position])
-}
-
 func (l *Lexer) isLetter(ch rune) bool {
 	return ch >= 'a' && ch <= 'z' ||
 		ch >= 'A' && ch <= 'Z' ||
-		ch == '_' ||
-		ch == '!' ||
-		ch == '?'
+		ch == '_'
 }
 
 func (l *Lexer) isDigit(ch rune) bool {
@@ -173,6 +302,17 @@ func (l *Lexer) skipDelim() {
 }
 
 func (l *Lexer) readRune() {
+	// step over the current rune before moving on, except on the first read and at EOF
+	if l.readPosition > 0 && l.position < len(l.input) {
+		l.offset += utf8.RuneLen(l.ch)
+		if l.ch == '\n' {
+			l.line++
+			l.column = 1
+		} else {
+			l.column++
+		}
+	}
+
 	if l.readPosition >= len(l.input) {
 		l.ch = 0
 	} else {
@@ -182,3 +322,12 @@ func (l *Lexer) readRune() {
 	l.position = l.readPosition
 	l.readPosition++
 }
+
+func (l *Lexer) curPosition() token.Position {
+	return token.Position{
+		Filename: l.filename,
+		Offset:   l.offset,
+		Line:     l.line,
+		Column:   l.column,
+	}
+}
@@ -1,13 +1,14 @@
 package lexer
 
 import (
+	"compiler/diagnostic"
 	"compiler/token"
 	"testing"
 )
 
 func TestNextToken_BasicToken(t *testing.T) {
 	// Arrange
-	input := `=+(){},;`
+	input := `=+(){}[],;:`
 
 	tests := []struct {
 		expectedType    token.TokenType
@@ -19,8 +20,11 @@ func TestNextToken_BasicToken(t *testing.T) {
 		{token.RPAREN, ")"},
 		{token.LBRACE, "{"},
 		{token.RBRACE, "}"},
+		{token.LBRACKET, "["},
+		{token.RBRACKET, "]"},
 		{token.COMMA, ","},
 		{token.SEMICOLON, ";"},
+		{token.COLON, ":"},
 		{token.EOF, ""},
 	}
 
@@ -131,3 +135,463 @@ func TestNextToken_Peek(t *testing.T) {
 		}
 	}
 }
+
+func TestNextToken_Position(t *testing.T) {
+	// Arrange
+	pos := func(offset, line, column int) token.Position {
+		return token.Position{Filename: "a.mk", Offset: offset, Line: line, Column: column}
+	}
+	input := "let x = 10;\n  x >= 5\n\"é\" y"
+
+	tests := []struct {
+		expectedLiteral string
+		expectedPos     token.Position
+		expectedEnd     token.Position
+	}{
+		{"let", pos(0, 1, 1), pos(3, 1, 4)},
+		{"x", pos(4, 1, 5), pos(5, 1, 6)},
+		{"=", pos(6, 1, 7), pos(7, 1, 8)},
+		{"10", pos(8, 1, 9), pos(10, 1, 11)},
+		{";", pos(10, 1, 11), pos(11, 1, 12)},
+		{"x", pos(14, 2, 3), pos(15, 2, 4)},
+		{">=", pos(16, 2, 5), pos(18, 2, 7)},
+		{"5", pos(19, 2, 8), pos(20, 2, 9)},
+		{"\"é\"", pos(21, 3, 1), pos(25, 3, 4)},
+		{"y", pos(26, 3, 5), pos(27, 3, 6)},
+		{"", pos(27, 3, 6), pos(27, 3, 6)},
+	}
+
+	l := New(input, WithFilename("a.mk"))
+
+	for i, tt := range tests {
+		token := l.NextToken()
+		if token.Literal != tt.expectedLiteral {
+			t.Fatalf("test %d error, got %s, expect %s", i, token.Literal,
+				tt.expectedLiteral)
+		}
+		if token.Pos != tt.expectedPos {
+			t.Fatalf("test %d error, got pos %+v, expect %+v", i, token.Pos,
+				tt.expectedPos)
+		}
+		if token.End != tt.expectedEnd {
+			t.Fatalf("test %d error, got end %+v, expect %+v", i, token.End,
+				tt.expectedEnd)
+		}
+	}
+}
+
+func TestNextToken_String(t *testing.T) {
+	// Arrange
+	input := `"hello" "a\n\t\"b\"\\" "\u{1F600}" "bad\q" "open
+"never closed`
+
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+	}{
+		{token.STRING, `"hello"`},
+		{token.STRING, `"a\n\t\"b\"\\"`},
+		{token.STRING, `"\u{1F600}"`},
+		{token.ILLEGAL, `"bad\q"`},
+		{token.ILLEGAL, `"open`},
+		{token.ILLEGAL, `"never closed`},
+		{token.EOF, ""},
+	}
+
+	l := New(input)
+
+	for i, tt := range tests {
+		token := l.NextToken()
+		if token.Type != tt.expectedType {
+			t.Fatalf("test %d error, got %s, expect %s", i, token.Type,
+				tt.expectedType)
+		}
+		if token.Literal != tt.expectedLiteral {
+			t.Fatalf("test %d error, got %s, expect %s", i, token.Literal,
+				tt.expectedLiteral)
+		}
+	}
+
+	expectedErrors := []struct {
+		code    string
+		message string
+	}{
+		{diagnostic.InvalidEscape, `1:40: unknown escape sequence \q`},
+		{diagnostic.UnterminatedString, "1:44: unterminated string literal"},
+		{diagnostic.UnterminatedString, "2:1: unterminated string literal"},
+	}
+	if len(l.Diagnostics()) != len(expectedErrors) {
+		t.Fatalf("got errors %v, expect %v", l.Diagnostics(), expectedErrors)
+	}
+	for i, d := range l.Diagnostics() {
+		if d.Error() != expectedErrors[i].message {
+			t.Fatalf("error %d, got %s, expect %s", i, d, expectedErrors[i].message)
+		}
+		if d.Code != expectedErrors[i].code {
+			t.Fatalf("error %d, got code %s, expect %s", i, d.Code, expectedErrors[i].code)
+		}
+	}
+	// the span covers the whole escape, and the q is not read again as text
+	if end := l.Diagnostics()[0].Span.End; end.String() != "1:42" {
+		t.Fatalf("escape ends at %s, expect 1:42", end)
+	}
+}
+
+func TestUnquote(t *testing.T) {
+	tests := []struct {
+		literal string
+		value   string
+	}{
+		{`""`, ""},
+		{`"abc"`, "abc"},
+		{`"a\nb\tc\r"`, "a\nb\tc\r"},
+		{`"\"\\"`, "\"\\"},
+		{`"\u{41}\u{e9}\u{1F600}"`, "Aé😀"},
+	}
+
+	for i, tt := range tests {
+		value, err := Unquote(tt.literal)
+		if err != nil {
+			t.Fatalf("test %d error, got %s", i, err)
+		}
+		if value != tt.value {
+			t.Fatalf("test %d error, got %q, expect %q", i, value, tt.value)
+		}
+	}
+
+	for _, literal := range []string{`"\q"`, `"\u{110000}"`, `"\u{}"`, `abc`} {
+		if _, err := Unquote(literal); err == nil {
+			t.Fatalf("expect error for %s", literal)
+		}
+	}
+}
+
+func TestNextToken_Comment(t *testing.T) {
+	// Arrange
+	input := `// leading
+let x = 1; // trailing
+/* block /* nested */ still comment */ x / 2
+/* open /* */`
+
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+	}{
+		{token.COMMENT, "// leading"},
+		{token.LET, "let"},
+		{token.IDENT, "x"},
+		{token.ASSIGN, "="},
+		{token.INT, "1"},
+		{token.SEMICOLON, ";"},
+		{token.COMMENT, "// trailing"},
+		{token.COMMENT, "/* block /* nested */ still comment */"},
+		{token.IDENT, "x"},
+		{token.DIVIDE, "/"},
+		{token.INT, "2"},
+		{token.ILLEGAL, "/* open /* */"},
+		{token.EOF, ""},
+	}
+
+	for _, withComments := range []bool{true, false} {
+		l := New(input)
+		if withComments {
+			l = New(input, WithComments())
+		}
+
+		for i, tt := range tests {
+			if tt.expectedType == token.COMMENT && !withComments {
+				continue
+			}
+			token := l.NextToken()
+			if token.Type != tt.expectedType {
+				t.Fatalf("test %d error, got %s, expect %s", i, token.Type,
+					tt.expectedType)
+			}
+			if token.Literal != tt.expectedLiteral {
+				t.Fatalf("test %d error, got %s, expect %s", i, token.Literal,
+					tt.expectedLiteral)
+			}
+		}
+
+		if len(l.Diagnostics()) != 1 || l.Diagnostics()[0].Code != diagnostic.UnterminatedComment {
+			t.Fatalf("expect one unterminated comment, got %v", l.Diagnostics())
+		}
+		if l.Diagnostics()[0].Error() != "4:1: unterminated block comment" {
+			t.Fatalf("got %s", l.Diagnostics()[0])
+		}
+	}
+}
+
+func TestNextToken_Trivia(t *testing.T) {
+	// Arrange
+	input := "// header\r\nlet x = 1;  // one\n\n\t/* block\n */ x /* mid */ + 2 \n/* open"
+
+	l := New(input, WithTrivia())
+
+	tokens := []token.Token{}
+	for {
+		tok := l.NextToken()
+		tokens = append(tokens, tok)
+		if tok.Type == token.EOF {
+			break
+		}
+		if tok.Type == token.COMMENT {
+			t.Fatalf("comments are trivia, got %v", tok)
+		}
+	}
+
+	got := ""
+	for _, tok := range tokens {
+		got += tok.FullText()
+	}
+	if got != input {
+		t.Fatalf("round trip error, got %q, expect %q", got, input)
+	}
+
+	tests := []struct {
+		index    int
+		leading  []token.TriviaKind
+		trailing []token.TriviaKind
+	}{
+		// let
+		{0, []token.TriviaKind{token.TRIVIA_COMMENT, token.TRIVIA_NEWLINE}, []token.TriviaKind{token.TRIVIA_WHITESPACE}},
+		// ;
+		{4, []token.TriviaKind{}, []token.TriviaKind{token.TRIVIA_WHITESPACE, token.TRIVIA_COMMENT}},
+		// x
+		{5, []token.TriviaKind{token.TRIVIA_NEWLINE, token.TRIVIA_NEWLINE, token.TRIVIA_WHITESPACE, token.TRIVIA_COMMENT, token.TRIVIA_WHITESPACE}, []token.TriviaKind{token.TRIVIA_WHITESPACE, token.TRIVIA_COMMENT, token.TRIVIA_WHITESPACE}},
+		// EOF
+		{8, []token.TriviaKind{token.TRIVIA_NEWLINE, token.TRIVIA_COMMENT}, nil},
+	}
+	for _, tt := range tests {
+		tok := tokens[tt.index]
+		if len(tok.Leading) != len(tt.leading) || len(tok.Trailing) != len(tt.trailing) {
+			t.Fatalf("token %d error, got leading %v trailing %v", tt.index, tok.Leading, tok.Trailing)
+		}
+		for i, kind := range tt.leading {
+			if tok.Leading[i].Kind != kind {
+				t.Fatalf("token %d leading %d error, got %s, expect %s", tt.index, i, tok.Leading[i].Kind, kind)
+			}
+		}
+		for i, kind := range tt.trailing {
+			if tok.Trailing[i].Kind != kind {
+				t.Fatalf("token %d trailing %d error, got %s, expect %s", tt.index, i, tok.Trailing[i].Kind, kind)
+			}
+		}
+	}
+
+	mid := tokens[5].Trailing[1]
+	if mid.Text != "/* mid */" || mid.Pos.Line != 5 || mid.Pos.Column != 7 {
+		t.Fatalf("got trivia %+v", mid)
+	}
+
+	if len(l.Diagnostics()) != 1 || l.Diagnostics()[0].Code != diagnostic.UnterminatedComment {
+		t.Fatalf("expect one unterminated comment, got %v", l.Diagnostics())
+	}
+}
+
+func TestNextToken_Number(t *testing.T) {
+	// Arrange
+	input := `42 3.14 0.5 1e9 1E+2 1.5e-3 7. 2e`
+
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+	}{
+		{token.INT, "42"},
+		{token.FLOAT, "3.14"},
+		{token.FLOAT, "0.5"},
+		{token.FLOAT, "1e9"},
+		{token.FLOAT, "1E+2"},
+		{token.FLOAT, "1.5e-3"},
+		{token.INT, "7"},
+		{token.ILLEGAL, "."},
+		{token.ILLEGAL, "2e"},
+		{token.EOF, ""},
+	}
+
+	l := New(input)
+
+	for i, tt := range tests {
+		token := l.NextToken()
+		if token.Type != tt.expectedType {
+			t.Fatalf("test %d error, got %s, expect %s", i, token.Type,
+				tt.expectedType)
+		}
+		if token.Literal != tt.expectedLiteral {
+			t.Fatalf("test %d error, got %s, expect %s", i, token.Literal,
+				tt.expectedLiteral)
+		}
+	}
+
+	expectedErrors := []string{
+		`1:30: illegal character "."`,
+		"1:32: exponent has no digits in 2e",
+	}
+	if len(l.Diagnostics()) != len(expectedErrors) {
+		t.Fatalf("got errors %v, expect %v", l.Diagnostics(), expectedErrors)
+	}
+	for i, d := range l.Diagnostics() {
+		if d.Error() != expectedErrors[i] {
+			t.Fatalf("error %d, got %s, expect %s", i, d, expectedErrors[i])
+		}
+	}
+}
+
+func TestNextToken_NumberBase(t *testing.T) {
+	// Arrange
+	input := `0xFF 0o755 0B1010 1_000_000 0755 1_0.5_0e1_0 0x 0b102 1__0 2_ 0o_7 00 0.5e1 089`
+
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+	}{
+		{token.INT, "0xFF"},
+		{token.INT, "0o755"},
+		{token.INT, "0B1010"},
+		{token.INT, "1_000_000"},
+		{token.ILLEGAL, "0755"},
+		{token.FLOAT, "1_0.5_0e1_0"},
+		{token.ILLEGAL, "0x"},
+		{token.ILLEGAL, "0b102"},
+		{token.ILLEGAL, "1__0"},
+		{token.ILLEGAL, "2_"},
+		{token.ILLEGAL, "0o_7"},
+		{token.INT, "00"},
+		{token.FLOAT, "0.5e1"},
+		{token.ILLEGAL, "089"},
+		{token.EOF, ""},
+	}
+
+	l := New(input)
+
+	for i, tt := range tests {
+		token := l.NextToken()
+		if token.Type != tt.expectedType {
+			t.Fatalf("test %d error, got %s, expect %s", i, token.Type,
+				tt.expectedType)
+		}
+		if token.Literal != tt.expectedLiteral {
+			t.Fatalf("test %d error, got %s, expect %s", i, token.Literal,
+				tt.expectedLiteral)
+		}
+	}
+
+	expectedErrors := []string{
+		"1:29: leading zero in decimal literal 0755",
+		"1:46: hexadecimal literal has no digits",
+		"1:49: invalid digit '2' in binary literal 0b102",
+		"1:55: '_' must separate digits in 1__0",
+		"1:60: '_' must separate digits in 2_",
+		"1:63: '_' must separate digits in 0o_7",
+		"1:77: leading zero in decimal literal 089",
+	}
+	if len(l.Diagnostics()) != len(expectedErrors) {
+		t.Fatalf("got errors %v, expect %v", l.Diagnostics(), expectedErrors)
+	}
+	for i, d := range l.Diagnostics() {
+		if d.Error() != expectedErrors[i] {
+			t.Fatalf("error %d, got %s, expect %s", i, d, expectedErrors[i])
+		}
+		if d.Code != diagnostic.MalformedNumber {
+			t.Fatalf("error %d, got code %s", i, d.Code)
+		}
+	}
+	if notes := l.Diagnostics()[0].Notes; len(notes) != 1 || notes[0] != "use 0o755 for octal, or 755 for decimal" {
+		t.Fatalf("got notes %v", notes)
+	}
+	if notes := l.Diagnostics()[6].Notes; len(notes) != 1 || notes[0] != "use 89 for decimal" {
+		t.Fatalf("got notes %v", notes)
+	}
+}
+
+func TestNextToken_Logical(t *testing.T) {
+	// Arrange
+	input := `a && b || c & d | e`
+
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+	}{
+		{token.IDENT, "a"},
+		{token.AND, "&&"},
+		{token.IDENT, "b"},
+		{token.OR, "||"},
+		{token.IDENT, "c"},
+		{token.ILLEGAL, "&"},
+		{token.IDENT, "d"},
+		{token.ILLEGAL, "|"},
+		{token.IDENT, "e"},
+		{token.EOF, ""},
+	}
+
+	l := New(input)
+
+	for i, tt := range tests {
+		token := l.NextToken()
+		if token.Type != tt.expectedType {
+			t.Fatalf("test %d error, got %s, expect %s", i, token.Type,
+				tt.expectedType)
+		}
+		if token.Literal != tt.expectedLiteral {
+			t.Fatalf("test %d error, got %s, expect %s", i, token.Literal,
+				tt.expectedLiteral)
+		}
+	}
+
+	if len(l.Diagnostics()) != 2 || l.Diagnostics()[0].Code != diagnostic.IllegalCharacter {
+		t.Fatalf("expect two illegal characters, got %v", l.Diagnostics())
+	}
+}
+
+func TestNextToken_Assign(t *testing.T) {
+	// Arrange
+	input := `x = 1; x += 2; x -= 3; x *= 4; x /= 5; x++ == x-- /=// c
+`
+
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+	}{
+		{token.IDENT, "x"},
+		{token.ASSIGN, "="},
+		{token.INT, "1"},
+		{token.SEMICOLON, ";"},
+		{token.IDENT, "x"},
+		{token.PLUS_ASSIGN, "+="},
+		{token.INT, "2"},
+		{token.SEMICOLON, ";"},
+		{token.IDENT, "x"},
+		{token.MINUS_ASSIGN, "-="},
+		{token.INT, "3"},
+		{token.SEMICOLON, ";"},
+		{token.IDENT, "x"},
+		{token.MULTI_ASSIGN, "*="},
+		{token.INT, "4"},
+		{token.SEMICOLON, ";"},
+		{token.IDENT, "x"},
+		{token.DIVIDE_ASSIGN, "/="},
+		{token.INT, "5"},
+		{token.SEMICOLON, ";"},
+		{token.IDENT, "x"},
+		{token.PLUSPLUS, "++"},
+		{token.EQ, "=="},
+		{token.IDENT, "x"},
+		{token.MINUSMINUS, "--"},
+		{token.DIVIDE_ASSIGN, "/="},
+		{token.EOF, ""},
+	}
+
+	l := New(input)
+
+	for i, tt := range tests {
+		token := l.NextToken()
+		if token.Type != tt.expectedType {
+			t.Fatalf("test %d error, got %s, expect %s", i, token.Type,
+				tt.expectedType)
+		}
+		if token.Literal != tt.expectedLiteral {
+			t.Fatalf("test %d error, got %s, expect %s", i, token.Literal,
+				tt.expectedLiteral)
+		}
+	}
+}
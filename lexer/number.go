@@ -0,0 +1,200 @@
+package lexer
+
+import (
+	"compiler/diagnostic"
+	"compiler/token"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// bases of integer literals written with a prefix like 0x
+var prefixBases = map[rune]int{
+	'x': 16, 'X': 16,
+	'o': 8, 'O': 8,
+	'b': 2, 'B': 2,
+}
+
+var baseNames = map[int]string{
+	16: "hexadecimal",
+	8:  "octal",
+	2:  "binary",
+}
+
+// read an INT like 42, 1_000, 0xFF, 0o755 or 0b1010, or a FLOAT like 3.14,
+// 1e9 or 1.5e-3. a . only starts a fraction when a digit follows it, so 1.
+// is INT 1 and ILLEGAL . an INT other than 0 cannot start with 0
+func (l *Lexer) readNumber(pos token.Position) (token.TokenType, string) {
+	beginPosition := l.position
+	if _, ok := prefixBases[l.peek()]; ok && l.ch == '0' {
+		return l.readPrefixedNumber(pos)
+	}
+
+	tokenType := token.INT
+	separated := l.readDigits()
+	if l.ch == '.' && l.isDigit(l.peek()) {
+		tokenType = token.FLOAT
+		l.readRune()
+		separated = l.readDigits() && separated
+	}
+
+	if l.ch == 'e' || l.ch == 'E' {
+		tokenType = token.FLOAT
+		l.readRune()
+		if l.ch == '+' || l.ch == '-' {
+			l.readRune()
+		}
+		if !l.isDigit(l.ch) {
+			literal := string(l.input[beginPosition:l.position])
+			l.addDiagnostic(diagnostic.Errorf(diagnostic.MalformedNumber,
+				diagnostic.Span{Pos: pos, End: l.curPosition()}, "exponent has no digits in %s", literal).
+				WithNote("write the exponent like 1e10 or 1.5e-3"))
+			return token.ILLEGAL, literal
+		}
+		separated = l.readDigits() && separated
+	}
+
+	literal := string(l.input[beginPosition:l.position])
+	if !separated {
+		l.addSeparatorError(pos, literal)
+		return token.ILLEGAL, literal
+	}
+	// NOTE: C, Go and Python readers take 0755 for octal, so rather than
+	// quietly read it as decimal 755 make them say which one they mean
+	if digits := strings.TrimLeft(literal, "0_"); tokenType == token.INT && literal[0] == '0' && digits != "" {
+		d := diagnostic.Errorf(diagnostic.MalformedNumber,
+			diagnostic.Span{Pos: pos, End: l.curPosition()}, "leading zero in decimal literal %s", literal)
+		if strings.IndexAny(digits, "89") == -1 {
+			d.WithNote("use 0o%s for octal, or %s for decimal", digits, digits)
+		} else {
+			d.WithNote("use %s for decimal", digits)
+		}
+		l.addDiagnostic(d)
+		return token.ILLEGAL, literal
+	}
+	return tokenType, literal
+}
+
+// 0x, 0o or 0b and the digits after it. letters and digits are read on
+// to the end of the word, so 0b102 is one malformed literal
+func (l *Lexer) readPrefixedNumber(pos token.Position) (token.TokenType, string) {
+	beginPosition := l.position
+	l.readRune()
+	base := prefixBases[l.ch]
+	l.readRune()
+
+	digitsPosition := l.position
+	for isAlphanumeric(l.ch) {
+		l.readRune()
+	}
+	literal := string(l.input[beginPosition:l.position])
+	digits := l.input[digitsPosition:l.position]
+	span := diagnostic.Span{Pos: pos, End: l.curPosition()}
+
+	if len(digits) == 0 {
+		l.addDiagnostic(diagnostic.Errorf(diagnostic.MalformedNumber,
+			span, "%s literal has no digits", baseNames[base]))
+		return token.ILLEGAL, literal
+	}
+	for _, r := range digits {
+		if r != '_' && digitValue(r) >= base {
+			l.addDiagnostic(diagnostic.Errorf(diagnostic.MalformedNumber,
+				span, "invalid digit %q in %s literal %s", r, baseNames[base], literal))
+			return token.ILLEGAL, literal
+		}
+	}
+	if !separatedByDigits(digits) {
+		l.addSeparatorError(pos, literal)
+		return token.ILLEGAL, literal
+	}
+	return token.INT, literal
+}
+
+// read decimal digits grouped by _, false if an _ is not between two digits
+func (l *Lexer) readDigits() bool {
+	beginPosition := l.position
+	for l.isDigit(l.ch) || l.ch == '_' {
+		l.readRune()
+	}
+	return separatedByDigits(l.input[beginPosition:l.position])
+}
+
+func (l *Lexer) addSeparatorError(pos token.Position, literal string) {
+	l.addDiagnostic(diagnostic.Errorf(diagnostic.MalformedNumber,
+		diagnostic.Span{Pos: pos, End: l.curPosition()}, "'_' must separate digits in %s", literal).
+		WithNote("write groups of digits like 1_000_000"))
+}
+
+func separatedByDigits(digits []rune) bool {
+	for i, r := range digits {
+		if r != '_' {
+			continue
+		}
+		if i == 0 || i == len(digits)-1 || digits[i-1] == '_' {
+			return false
+		}
+	}
+	return true
+}
+
+// the rune after ch, 0 at the end of input
+func (l *Lexer) peek() rune {
+	if l.readPosition >= len(l.input) {
+		return 0
+	}
+	return l.input[l.readPosition]
+}
+
+func isAlphanumeric(ch rune) bool {
+	return ch >= 'a' && ch <= 'z' ||
+		ch >= 'A' && ch <= 'Z' ||
+		ch >= '0' && ch <= '9' ||
+		ch == '_'
+}
+
+// value of a digit in bases up to 36, 36 for anything else
+func digitValue(ch rune) int {
+	switch {
+	case ch >= '0' && ch <= '9':
+		return int(ch - '0')
+	case ch >= 'a' && ch <= 'z':
+		return int(ch-'a') + 10
+	case ch >= 'A' && ch <= 'Z':
+		return int(ch-'A') + 10
+	default:
+		return 36
+	}
+}
+
+// decode the value of an INT literal, the error says if it does not fit in 64 bits
+func ParseInteger(literal string) (int64, error) {
+	digits := strings.ReplaceAll(literal, "_", "")
+	base := 10
+	if len(digits) > 2 && digits[0] == '0' {
+		if b, ok := prefixBases[rune(digits[1])]; ok {
+			base = b
+			digits = digits[2:]
+		}
+	}
+
+	value, err := strconv.ParseInt(digits, base, 64)
+	if err != nil {
+		if numErr, ok := err.(*strconv.NumError); ok && numErr.Err == strconv.ErrRange {
+			return 0, fmt.Errorf("integer literal %s overflows int64", literal)
+		}
+		return 0, fmt.Errorf("invalid integer literal %s", literal)
+	}
+	return value, nil
+}
+
+// decode the value of a FLOAT literal
+func ParseFloat(literal string) (float64, error) {
+	value, err := strconv.ParseFloat(strings.ReplaceAll(literal, "_", ""), 64)
+	if err != nil {
+		if numErr, ok := err.(*strconv.NumError); ok && numErr.Err == strconv.ErrRange {
+			return value, fmt.Errorf("float literal %s is out of range", literal)
+		}
+		return 0, fmt.Errorf("invalid float literal %s", literal)
+	}
+	return value, nil
+}
@@ -0,0 +1,152 @@
+package lexer
+
+import (
+	"compiler/diagnostic"
+	"compiler/token"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// single character escapes allowed after a backslash
+var escapes = map[rune]rune{
+	'n':  '\n',
+	't':  '\t',
+	'r':  '\r',
+	'"':  '"',
+	'\\': '\\',
+}
+
+// scan a double quoted string starting at pos, l.ch is the opening quote.
+// on success l.ch is left on the closing quote.
+func (l *Lexer) readString(pos token.Position) (string, bool) {
+	beginPosition := l.position
+	valid := true
+
+	l.readRune()
+	for l.ch != '"' {
+		switch l.ch {
+		case 0, '\n':
+			d := diagnostic.Errorf(diagnostic.UnterminatedString,
+				diagnostic.Span{Pos: pos, End: l.curPosition()}, "unterminated string literal")
+			if l.ch == '\n' {
+				d.WithNote("string literals cannot span lines, use \\n instead")
+			}
+			l.addDiagnostic(d)
+			return string(l.input[beginPosition:l.position]), false
+		case '\\':
+			escapePos := l.curPosition()
+			l.readRune()
+			if msg := l.readEscape(); msg != "" {
+				l.addDiagnostic(diagnostic.Errorf(diagnostic.InvalidEscape,
+					diagnostic.Span{Pos: escapePos, End: l.curPosition()}, "%s", msg).
+					WithNote(`valid escapes are \n \t \r \" \\ and \u{...}`))
+				valid = false
+			}
+		default:
+			l.readRune()
+		}
+	}
+
+	return string(l.input[beginPosition : l.position+1]), valid
+}
+
+// check the escape after a backslash and step over it.
+// return a message describing the problem, or "" if it is fine
+func (l *Lexer) readEscape() string {
+	if _, ok := escapes[l.ch]; ok {
+		l.readRune()
+		return ""
+	}
+	if l.ch != 'u' {
+		if l.ch == 0 || l.ch == '\n' {
+			// reported as an unterminated string by the caller
+			return ""
+		}
+		ch := l.ch
+		l.readRune()
+		return fmt.Sprintf("unknown escape sequence \\%c", ch)
+	}
+
+	l.readRune()
+	if l.ch != '{' {
+		return "expect { after \\u"
+	}
+	l.readRune()
+	beginPosition := l.position
+	for isHexDigit(l.ch) {
+		l.readRune()
+	}
+	digits := string(l.input[beginPosition:l.position])
+	if l.ch != '}' {
+		return "expect } to close \\u{...}"
+	}
+	l.readRune()
+
+	if _, err := decodeCodePoint(digits); err != nil {
+		return err.Error()
+	}
+	return ""
+}
+
+// decode the value of a string literal including its quotes
+func Unquote(literal string) (string, error) {
+	if len(literal) < 2 || literal[0] != '"' || literal[len(literal)-1] != '"' {
+		return "", fmt.Errorf("invalid string literal %s", literal)
+	}
+	input := []rune(literal[1 : len(literal)-1])
+
+	var sb strings.Builder
+	for i := 0; i < len(input); i++ {
+		if input[i] != '\\' {
+			sb.WriteRune(input[i])
+			continue
+		}
+
+		i++
+		if i >= len(input) {
+			return "", fmt.Errorf("unterminated escape sequence")
+		}
+		if r, ok := escapes[input[i]]; ok {
+			sb.WriteRune(r)
+			continue
+		}
+		if input[i] != 'u' || i+1 >= len(input) || input[i+1] != '{' {
+			return "", fmt.Errorf("unknown escape sequence \\%c", input[i])
+		}
+
+		end := i + 2
+		for end < len(input) && input[end] != '}' {
+			end++
+		}
+		if end >= len(input) {
+			return "", fmt.Errorf("expect } to close \\u{...}")
+		}
+		r, err := decodeCodePoint(string(input[i+2 : end]))
+		if err != nil {
+			return "", err
+		}
+		sb.WriteRune(r)
+		i = end
+	}
+
+	return sb.String(), nil
+}
+
+func decodeCodePoint(digits string) (rune, error) {
+	if len(digits) == 0 || len(digits) > 6 {
+		return 0, fmt.Errorf("\\u{...} needs 1 to 6 hex digits, got %q", digits)
+	}
+	value, err := strconv.ParseUint(digits, 16, 32)
+	if err != nil || !utf8.ValidRune(rune(value)) {
+		return 0, fmt.Errorf("invalid unicode code point \\u{%s}", digits)
+	}
+	return rune(value), nil
+}
+
+func isHexDigit(ch rune) bool {
+	return '0' <= ch && ch <= '9' ||
+		'a' <= ch && ch <= 'f' ||
+		'A' <= ch && ch <= 'F'
+}
@@ -0,0 +1,65 @@
+package lexer
+
+import (
+	"compiler/token"
+)
+
+// attach whitespace and comments to the tokens around them, so that
+// concatenating Token.FullText of every token up to and including EOF
+// gives back the source byte for byte (for valid UTF-8 input).
+//
+// trailing trivia runs up to the end of the line, everything after
+// belongs to the leading trivia of the next token. comments are trivia
+// in this mode and never returned as COMMENT tokens
+func WithTrivia() Option {
+	return func(l *Lexer) {
+		l.keepTrivia = true
+	}
+}
+
+func (l *Lexer) nextTokenWithTrivia() token.Token {
+	leading := l.readTrivia(false)
+	tok := l.scanToken()
+	tok.Leading = leading
+	if tok.Type != token.EOF {
+		tok.Trailing = l.readTrivia(true)
+	}
+	return tok
+}
+
+// read whitespace, newlines and comments, stop before a newline if trailing
+func (l *Lexer) readTrivia(trailing bool) []token.Trivia {
+	trivia := []token.Trivia{}
+
+	for {
+		pos := l.curPosition()
+		beginPosition := l.position
+
+		var kind token.TriviaKind
+		switch {
+		case l.ch == '\n':
+			if trailing {
+				return trivia
+			}
+			kind = token.TRIVIA_NEWLINE
+			l.readRune()
+		case l.ch == ' ' || l.ch == '\t' || l.ch == '\r':
+			kind = token.TRIVIA_WHITESPACE
+			for l.ch == ' ' || l.ch == '\t' || l.ch == '\r' {
+				l.readRune()
+			}
+		case l.atComment():
+			// NOTE: an unterminated block comment is still reported
+			kind = token.TRIVIA_COMMENT
+			l.readComment()
+		default:
+			return trivia
+		}
+
+		trivia = append(trivia, token.Trivia{
+			Kind: kind,
+			Text: string(l.input[beginPosition:l.position]),
+			Pos:  pos,
+		})
+	}
+}
@@ -5,14 +5,56 @@ import (
 	"fmt"
 	"os"
 	"os/user"
+	"path/filepath"
 )
 
+// kept in the home directory of the user
+const historyFile = ".shagua_history"
+
+const usage = `Usage:
+	shagua                  start the REPL
+	shagua run <file>       evaluate a file
+	shagua tokens <file>    print the tokens of a file
+	shagua ast <file>       print the syntax tree of a file
+	shagua check <file>     report diagnostics, exit 1 if there are any
+	shagua fmt <file>       print a file in the canonical style
+
+<file> can be - to read from stdin.
+`
+
+var commands = map[string]func(filename string) int{
+	"run":    runFile,
+	"tokens": printTokens,
+	"ast":    printAst,
+	"check":  checkFile,
+	"fmt":    formatFile,
+}
+
 func main() {
+	if len(os.Args) == 1 {
+		startRepl()
+		return
+	}
+
+	command, ok := commands[os.Args[1]]
+	if !ok || len(os.Args) != 3 {
+		if os.Args[1] != "help" && os.Args[1] != "-h" && os.Args[1] != "--help" {
+			fmt.Fprintf(os.Stderr, "shagua: bad arguments %q\n\n", os.Args[1:])
+			fmt.Fprint(os.Stderr, usage)
+			os.Exit(2)
+		}
+		fmt.Print(usage)
+		return
+	}
+	os.Exit(command(os.Args[2]))
+}
+
+func startRepl() {
 	user, err := user.Current()
 	if err != nil {
 		panic(err)
 	}
 	fmt.Printf("Hello %s! This is the Monkey programming language!\n", user.Username)
-	fmt.Printf("Type in commands\n")
-	repl.Start(os.Stdin, os.Stdout)
+	fmt.Printf("Type in commands, :help for more\n")
+	repl.StartTerminal(filepath.Join(user.HomeDir, historyFile))
 }
@@ -0,0 +1,98 @@
+package object
+
+import (
+	"hash/fnv"
+	"strconv"
+)
+
+var _ Object = (*Hash)(nil)
+var _ Hashable = (*Integer)(nil)
+var _ Hashable = (*Boolean)(nil)
+var _ Hashable = (*String)(nil)
+
+const HASH_OBJ ObjectType = "HASH"
+
+// equal values have equal keys, and a key is the same in every run
+type HashKey struct {
+	Type  ObjectType
+	Value uint64
+}
+
+// values that can be used as hash keys
+type Hashable interface {
+	Object
+	HashKey() HashKey
+}
+
+func (i *Integer) HashKey() HashKey {
+	return HashKey{Type: i.Type(), Value: uint64(i.Value)}
+}
+
+func (b *Boolean) HashKey() HashKey {
+	var value uint64
+	if b.Value {
+		value = 1
+	}
+	return HashKey{Type: b.Type(), Value: value}
+}
+
+// NOTE: FNV-1a, unlike the seeded hash of Go maps it does not change between runs
+func (s *String) HashKey() HashKey {
+	h := fnv.New64a()
+	h.Write([]byte(s.Value))
+	return HashKey{Type: s.Type(), Value: h.Sum64()}
+}
+
+type HashPair struct {
+	Key   Object
+	Value Object
+}
+
+// pairs are kept in insertion order, so printing and iterating are stable
+type Hash struct {
+	Pairs map[HashKey]HashPair
+	Keys  []HashKey
+}
+
+func NewHash() *Hash {
+	return &Hash{Pairs: map[HashKey]HashPair{}}
+}
+
+func (h *Hash) Type() ObjectType {
+	return HASH_OBJ
+}
+
+func (h *Hash) Inspect() string {
+	return inspect(h, map[Object]bool{})
+}
+
+// a key as it reads when printed, strings are quoted so "1" and 1 differ
+func InspectKey(key Object) string {
+	if str, ok := key.(*String); ok {
+		return strconv.Quote(str.Value)
+	}
+	return key.Inspect()
+}
+
+func (h *Hash) Get(key Hashable) (Object, bool) {
+	pair, ok := h.Pairs[key.HashKey()]
+	return pair.Value, ok
+}
+
+// a new key goes last, an existing one keeps its place
+func (h *Hash) Set(key Hashable, value Object) {
+	hashKey := key.HashKey()
+	if _, ok := h.Pairs[hashKey]; !ok {
+		h.Keys = append(h.Keys, hashKey)
+	}
+	h.Pairs[hashKey] = HashPair{Key: key, Value: value}
+}
+
+// pairs in insertion order
+func (h *Hash) Ordered() []HashPair {
+	pairs := []HashPair{}
+	for _, key := range h.Keys {
+		pairs = append(pairs, h.Pairs[key])
+	}
+	return pairs
+}
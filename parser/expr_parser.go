@@ -2,10 +2,10 @@ package parser
 
 import (
 	"compiler/ast"
+	"compiler/diagnostic"
+	"compiler/lexer"
 	"compiler/token"
-	"strconv"
-
-	"github.com/golang/glog"
+	"math"
 )
 
 type (
@@ -32,11 +32,16 @@ func NewExprParser(parser *Parser) *ExprParser {
 	p.registerPrefix(token.IF, p.parseIfExpression)
 	p.registerPrefix(token.IDENT, p.parseIndentifier)
 	p.registerPrefix(token.INT, p.parseInteger)
+	p.registerPrefix(token.FLOAT, p.parseFloat)
+	p.registerPrefix(token.STRING, p.parseString)
 	p.registerPrefix(token.BANG, p.parsePrefixExpression)
 	p.registerPrefix(token.MINUS, p.parsePrefixExpression)
 	p.registerPrefix(token.LPAREN, p.parseParem)
 	p.registerPrefix(token.TRUE, p.parseBoolean)
 	p.registerPrefix(token.FALSE, p.parseBoolean)
+	p.registerPrefix(token.FUNCTION, p.parseFnExpression)
+	p.registerPrefix(token.LBRACKET, p.parseArrayLiteral)
+	p.registerPrefix(token.LBRACE, p.parseHashLiteral)
 
 	p.registerInfix(token.PLUS, p.parseInfixExpression)
 	p.registerInfix(token.MINUS, p.parseInfixExpression)
@@ -47,11 +52,21 @@ func NewExprParser(parser *Parser) *ExprParser {
 	p.registerInfix(token.LE, p.parseInfixExpression)
 	p.registerInfix(token.LT, p.parseInfixExpression)
 	p.registerInfix(token.EQ, p.parseInfixExpression)
+	p.registerInfix(token.NE, p.parseInfixExpression)
+	p.registerInfix(token.AND, p.parseInfixExpression)
+	p.registerInfix(token.OR, p.parseInfixExpression)
+	p.registerInfix(token.LPAREN, p.parseCallExpression)
+	p.registerInfix(token.LBRACKET, p.parseIndexExpression)
+	p.registerInfix(token.WHAT, p.parseConditionalExpression)
+	p.registerInfix(token.ASSIGN, p.parseAssignExpression)
+	p.registerInfix(token.PLUS_ASSIGN, p.parseAssignExpression)
+	p.registerInfix(token.MINUS_ASSIGN, p.parseAssignExpression)
+	p.registerInfix(token.MULTI_ASSIGN, p.parseAssignExpression)
+	p.registerInfix(token.DIVIDE_ASSIGN, p.parseAssignExpression)
 
 	// NOTE: treat suffix as infix without right expr
 	p.registerInfix(token.PLUSPLUS, p.parseSuffixExpression)
 	p.registerInfix(token.MINUSMINUS, p.parseSuffixExpression)
-	p.registerInfix(token.WHAT, p.parseSuffixExpression)
 	return p
 }
 
@@ -59,10 +74,14 @@ func (p *ExprParser) ParseExpreesion(precedence int) ast.Expression {
 	// NOTE: check if we have a prefixFn associated with curToken
 	prefix := p.prefixParseFns[p.curToken.Type]
 	if prefix == nil {
+		p.noPrefixParseFnError()
 		return nil
 	}
 
 	leftExp := prefix()
+	if leftExp == nil {
+		return nil
+	}
 
 	for !p.peekTokenIs(token.SEMICOLON) &&
 		precedence < findPrecedence(p.peekToken.Type) {
@@ -73,12 +92,52 @@ func (p *ExprParser) ParseExpreesion(precedence int) ast.Expression {
 		p.nextToken()
 
 		leftExp = infix(leftExp)
+		if leftExp == nil {
+			return nil
+		}
 	}
 
-	glog.Error(leftExp.TokenLiteral())
 	return leftExp
 }
 
+func (p *ExprParser) noPrefixParseFnError() {
+	// the lexer already reported why the token is ILLEGAL
+	if p.curTokenIs(token.ILLEGAL) {
+		return
+	}
+	p.addDiagnostic(diagnostic.Errorf(diagnostic.MissingExpression,
+		diagnostic.TokenSpan(p.curToken), "Expect expression, got %v", p.curToken.Type).
+		WithNote("expected one of: %s", joinTokenTypes(p.expectedPrefix())))
+}
+
+// tokens that can start an expression
+func (p *ExprParser) expectedPrefix() []token.TokenType {
+	expected := []token.TokenType{}
+	for t := range p.prefixParseFns {
+		expected = append(expected, t)
+	}
+	return sortTokenTypes(expected)
+}
+
+// tokens that can follow a complete expression: the given ones or an operator
+func (p *ExprParser) expectedAfterExpression(ts ...token.TokenType) []token.TokenType {
+	infix := []token.TokenType{}
+	for t := range p.infixParseFns {
+		infix = append(infix, t)
+	}
+	return append(ts, sortTokenTypes(infix)...)
+}
+
+// like expectClosing, also list what could have continued the expression
+func (p *ExprParser) expectAfterExpression(t token.TokenType, open token.Token, others ...token.TokenType) bool {
+	if p.expectClosing(t, open) {
+		return true
+	}
+	expected := p.expectedAfterExpression(append([]token.TokenType{t}, others...)...)
+	p.lastDiagnostic().WithNote("expected one of: %s", joinTokenTypes(expected))
+	return false
+}
+
 func (p *ExprParser) registerPrefix(t token.TokenType, f prefixParseFn) {
 	p.prefixParseFns[t] = f
 }
@@ -94,8 +153,10 @@ func (p *ExprParser) parsePrefixExpression() ast.Expression {
 	}
 
 	p.nextToken()
-	precedence := findPrecedence(p.curToken.Type)
-	expr.Right = p.ParseExpreesion(precedence)
+	expr.Right = p.ParseExpreesion(PREFIX)
+	if expr.Right == nil {
+		return nil
+	}
 
 	return expr
 }
@@ -111,6 +172,9 @@ func (p *ExprParser) parseInfixExpression(left ast.Expression) ast.Expression {
 	precedence := findPrecedence(p.curToken.Type)
 	p.nextToken()
 	expr.Right = p.ParseExpreesion(precedence)
+	if expr.Right == nil {
+		return nil
+	}
 
 	return expr
 }
@@ -123,6 +187,55 @@ func (p *ExprParser) parseSuffixExpression(left ast.Expression) ast.Expression {
 	return expr
 }
 
+// <cond> ? <expr> : <expr>, right associative so a ? b : c ? d : e
+// groups as a ? b : (c ? d : e)
+func (p *ExprParser) parseConditionalExpression(condition ast.Expression) ast.Expression {
+	expr := &ast.ConditionalExpression{
+		Token:     p.curToken,
+		Condition: condition,
+	}
+
+	what := p.curToken
+	p.nextToken()
+	expr.Consequence = p.ParseExpreesion(LOWEST)
+	if expr.Consequence == nil || !p.expectAfterExpression(token.COLON, what) {
+		return nil
+	}
+
+	p.nextToken()
+	expr.Alternative = p.ParseExpreesion(CONDITIONAL - 1)
+	if expr.Alternative == nil {
+		return nil
+	}
+	return expr
+}
+
+// <target> = <expr>, right associative so a = b = 1 groups as a = (b = 1).
+// only a name or an index like a[i] can be assigned to
+func (p *ExprParser) parseAssignExpression(target ast.Expression) ast.Expression {
+	expr := &ast.AssignExpression{
+		Token:  p.curToken,
+		Target: target,
+	}
+
+	// NOTE: the span shows what the target is, printing it could walk into a
+	// node that did not finish parsing
+	switch target.(type) {
+	case *ast.Identifier, *ast.IndexExpression:
+	default:
+		p.addDiagnostic(diagnostic.Errorf(diagnostic.InvalidAssignment,
+			diagnostic.NodeSpan(target), "cannot assign to this expression").
+			WithNote("only a name or an index like a[i] can be assigned to"))
+	}
+
+	p.nextToken()
+	expr.Value = p.ParseExpreesion(ASSIGN - 1)
+	if expr.Value == nil {
+		return nil
+	}
+	return expr
+}
+
 func (p *ExprParser) parseIndentifier() ast.Expression {
 	return &ast.Identifier{
 		Token: p.curToken,
@@ -131,18 +244,21 @@ func (p *ExprParser) parseIndentifier() ast.Expression {
 }
 
 func (p *ExprParser) parseParem() ast.Expression {
+	lparen := p.curToken
 	p.nextToken()
 	expr := p.ParseExpreesion(LOWEST)
-	if !p.expectPeek(token.RPAREN) {
+	if expr == nil || !p.expectAfterExpression(token.RPAREN, lparen) {
 		return nil
 	}
 	return expr
 }
 
 func (p *ExprParser) parseInteger() ast.Expression {
-	value, err := strconv.ParseInt(p.curToken.Literal, 10, 64)
+	value, err := lexer.ParseInteger(p.curToken.Literal)
 	if err != nil {
-		p.addPeekError(p.curToken.Type)
+		p.addDiagnostic(diagnostic.Errorf(diagnostic.IntegerOverflow,
+			diagnostic.TokenSpan(p.curToken), "%v", err).
+			WithNote("integer literals can be at most %d", int64(math.MaxInt64)))
 	}
 
 	return &ast.IntegerLiteral{
@@ -151,6 +267,32 @@ func (p *ExprParser) parseInteger() ast.Expression {
 	}
 }
 
+func (p *ExprParser) parseFloat() ast.Expression {
+	value, err := lexer.ParseFloat(p.curToken.Literal)
+	if err != nil {
+		p.addDiagnostic(diagnostic.Errorf(diagnostic.InvalidLiteral,
+			diagnostic.TokenSpan(p.curToken), "%v", err))
+	}
+
+	return &ast.FloatLiteral{
+		Token: p.curToken,
+		Value: value,
+	}
+}
+
+func (p *ExprParser) parseString() ast.Expression {
+	value, err := lexer.Unquote(p.curToken.Literal)
+	if err != nil {
+		p.addDiagnostic(diagnostic.Errorf(diagnostic.InvalidLiteral,
+			diagnostic.TokenSpan(p.curToken), "%v", err))
+	}
+
+	return &ast.StringLiteral{
+		Token: p.curToken,
+		Value: value,
+	}
+}
+
 func (p *ExprParser) parseBoolean() ast.Expression {
 	return &ast.Boolean{
 		Token: p.curToken,
@@ -165,19 +307,234 @@ func (p *ExprParser) parseIfExpression() ast.Expression {
 		Consequence: &ast.BlockStatement{},
 		Alternatvie: nil,
 	}
-	p.expectPeek(token.LPAREN)
+	if !p.expectPeek(token.LPAREN) {
+		return nil
+	}
+	lparen := p.curToken
 	p.nextToken()
 	expr.Condition = p.ParseExpreesion(LOWEST)
-	p.expectPeek(token.RPAREN)
+	if expr.Condition == nil || !p.expectAfterExpression(token.RPAREN, lparen) {
+		return nil
+	}
 
-	p.expectPeek(token.LBRACE)
+	if !p.expectPeek(token.LBRACE) {
+		return nil
+	}
 	expr.Consequence = p.stmtParser.parseBlockStatement()
 
 	if p.peekTokenIs(token.ELSE) {
 		p.nextToken()
-		p.expectPeek(token.LBRACE)
+		if !p.expectPeek(token.LBRACE) {
+			return nil
+		}
 		expr.Alternatvie = p.stmtParser.parseBlockStatement()
 	}
 
 	return expr
 }
+
+// fn(<ident>, <ident>, ...) <block>
+func (p *ExprParser) parseFnExpression() ast.Expression {
+	expr := &ast.FnExpression{
+		Token: p.curToken,
+		Param: []ast.Identifier{},
+	}
+
+	if !p.expectPeek(token.LPAREN) {
+		return nil
+	}
+	params, ok := p.parseFnParams()
+	if !ok {
+		return nil
+	}
+	expr.Param = params
+
+	if !p.expectPeek(token.LBRACE) {
+		return nil
+	}
+	// NOTE: a loop around the fn is not one break can leave from inside it
+	loopDepth := p.loopDepth
+	p.loopDepth = 0
+	expr.Body = *p.stmtParser.parseBlockStatement()
+	p.loopDepth = loopDepth
+
+	return expr
+}
+
+// curToken is (, stop at the matching )
+func (p *ExprParser) parseFnParams() ([]ast.Identifier, bool) {
+	params := []ast.Identifier{}
+	lparen := p.curToken
+
+	if p.peekTokenIs(token.RPAREN) {
+		p.nextToken()
+		return params, true
+	}
+
+	if !p.expectPeek(token.IDENT) {
+		return nil, false
+	}
+	params = append(params, ast.Identifier{Token: p.curToken, Value: p.curToken.Literal})
+
+	for p.peekTokenIs(token.COMMA) {
+		p.nextToken()
+		if !p.expectPeek(token.IDENT) {
+			return nil, false
+		}
+		params = append(params, ast.Identifier{Token: p.curToken, Value: p.curToken.Literal})
+	}
+
+	if !p.expectClosing(token.RPAREN, lparen) {
+		return nil, false
+	}
+	return params, true
+}
+
+// <Expr>(<args>), curToken is (
+func (p *ExprParser) parseCallExpression(function ast.Expression) ast.Expression {
+	expr := &ast.CallExpression{
+		Token:    p.curToken,
+		Function: function,
+	}
+
+	args, ok := p.parseExpressionList(token.RPAREN)
+	if !ok {
+		return nil
+	}
+	expr.Arguments = args
+	expr.Rparen = p.curToken
+
+	return expr
+}
+
+// [<expr>, <expr>, ...]
+func (p *ExprParser) parseArrayLiteral() ast.Expression {
+	array := &ast.ArrayLiteral{
+		Token: p.curToken,
+	}
+
+	elements, ok := p.parseExpressionList(token.RBRACKET)
+	if !ok {
+		return nil
+	}
+	array.Elements = elements
+	array.Rbracket = p.curToken
+
+	return array
+}
+
+// {<expr>: <expr>, ...}, only in expression position, blocks follow if and fn
+func (p *ExprParser) parseHashLiteral() ast.Expression {
+	hash := &ast.HashLiteral{
+		Token:  p.curToken,
+		Keys:   []ast.Expression{},
+		Values: []ast.Expression{},
+	}
+	lbrace := p.curToken
+
+	if p.peekTokenIs(token.RBRACE) {
+		p.nextToken()
+		hash.Rbrace = p.curToken
+		return hash
+	}
+
+	for {
+		p.nextToken()
+		key := p.ParseExpreesion(LOWEST)
+		if key == nil || !p.expectPeek(token.COLON) {
+			return nil
+		}
+
+		p.nextToken()
+		value := p.ParseExpreesion(LOWEST)
+		if value == nil {
+			return nil
+		}
+		hash.Keys = append(hash.Keys, key)
+		hash.Values = append(hash.Values, value)
+
+		if !p.peekTokenIs(token.COMMA) {
+			break
+		}
+		p.nextToken()
+	}
+
+	if !p.expectAfterExpression(token.RBRACE, lbrace, token.COMMA) {
+		return nil
+	}
+	hash.Rbrace = p.curToken
+
+	return hash
+}
+
+// <expr>[<expr>] or a slice <expr>[<expr>:<expr>], both bounds of a slice
+// are optional
+func (p *ExprParser) parseIndexExpression(left ast.Expression) ast.Expression {
+	lbracket := p.curToken
+
+	var index ast.Expression
+	if !p.peekTokenIs(token.COLON) {
+		p.nextToken()
+		index = p.ParseExpreesion(LOWEST)
+		if index == nil {
+			return nil
+		}
+	}
+
+	if !p.peekTokenIs(token.COLON) {
+		if !p.expectAfterExpression(token.RBRACKET, lbracket, token.COLON) {
+			return nil
+		}
+		return &ast.IndexExpression{
+			Token:    lbracket,
+			Left:     left,
+			Index:    index,
+			Rbracket: p.curToken,
+		}
+	}
+
+	p.nextToken()
+	slice := &ast.SliceExpression{
+		Token: lbracket,
+		Left:  left,
+		Low:   index,
+	}
+	if !p.peekTokenIs(token.RBRACKET) {
+		p.nextToken()
+		slice.High = p.ParseExpreesion(LOWEST)
+		if slice.High == nil {
+			return nil
+		}
+	}
+	if !p.expectAfterExpression(token.RBRACKET, lbracket) {
+		return nil
+	}
+	slice.Rbracket = p.curToken
+
+	return slice
+}
+
+// comma separated expressions, curToken opens the list, stop at end
+func (p *ExprParser) parseExpressionList(end token.TokenType) ([]ast.Expression, bool) {
+	list := []ast.Expression{}
+	open := p.curToken
+
+	if p.peekTokenIs(end) {
+		p.nextToken()
+		return list, true
+	}
+
+	p.nextToken()
+	list = append(list, p.ParseExpreesion(LOWEST))
+
+	for p.peekTokenIs(token.COMMA) {
+		p.nextToken()
+		p.nextToken()
+		list = append(list, p.ParseExpreesion(LOWEST))
+	}
+
+	if !p.expectAfterExpression(end, open, token.COMMA) {
+		return nil, false
+	}
+	return list, true
+}
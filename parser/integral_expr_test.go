@@ -2,7 +2,10 @@ package parser
 
 import (
 	"compiler/ast"
+	"compiler/diagnostic"
 	"compiler/lexer"
+	"fmt"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -12,7 +15,7 @@ import (
 func TestParseIntegerExpression(t *testing.T) {
 	table := []struct {
 		input string
-		value int
+		value int64
 	}{
 		{
 			"5;", 5,
@@ -20,6 +23,14 @@ func TestParseIntegerExpression(t *testing.T) {
 		{
 			"0;", 0,
 		},
+		{"00", 0},
+		{"0xFF", 255},
+		{"0Xff_ff", 65535},
+		{"0o755", 493},
+		{"0b1010", 10},
+		{"1_000_000", 1000000},
+		{"9223372036854775807", 9223372036854775807},
+		{"0x7fff_ffff_ffff_ffff", 9223372036854775807},
 	}
 
 	for _, data := range table {
@@ -35,8 +46,64 @@ func TestParseIntegerExpression(t *testing.T) {
 		intLiteral, ok := expr.Expression.(*ast.IntegerLiteral)
 		require.True(t, ok)
 
-		assert.Equal(t, int64(data.value), intLiteral.Value)
+		assert.Equal(t, data.value, intLiteral.Value)
+	}
+
+	p := New(lexer.New("let mask = 0755;"))
+	p.ParseProgram()
+	diags := p.Diagnostics()
+	require.Len(t, diags, 1)
+	assert.Equal(t, diagnostic.MalformedNumber, diags[0].Code)
+	assert.Equal(t, "1:12: leading zero in decimal literal 0755", diags[0].Error())
+	assert.Equal(t, []string{"use 0o755 for octal, or 755 for decimal"}, diags[0].Notes)
+}
+
+func TestParseIntegerOverflow(t *testing.T) {
+	for _, input := range []string{"9223372036854775808", "0xFFFF_FFFF_FFFF_FFFF", "let x = 1 + 0b1" + strings.Repeat("0", 64)} {
+		p := New(lexer.New(input))
+		p.ParseProgram()
+		diags := p.Diagnostics()
+		require.Len(t, diags, 1, input)
+
+		literal := input[strings.LastIndex(input, " ")+1:]
+		assert.Equal(t, diagnostic.IntegerOverflow, diags[0].Code)
+		assert.Equal(t, fmt.Sprintf("integer literal %s overflows int64", literal), diags[0].Message)
+		assert.Equal(t, []string{"integer literals can be at most 9223372036854775807"}, diags[0].Notes)
+		assert.Equal(t, strings.LastIndex(input, " ")+1, diags[0].Span.Pos.Offset)
+		assert.Equal(t, len(input), diags[0].Span.End.Offset)
+	}
+}
+
+func TestParseFloatExpression(t *testing.T) {
+	table := []struct {
+		input string
+		value float64
+	}{
+		{"3.14;", 3.14},
+		{"1.5e-3", 0.0015},
+		{"2E3", 2000},
+	}
+
+	for _, data := range table {
+		l := lexer.New(data.input)
+		p := New(l)
+		program := p.ParseProgram()
+		require.Equal(t, []error{}, p.Errors())
+		require.Equal(t, 1, len(program.Statements))
+
+		expr, ok := (program.Statements[0]).(*ast.ExpressionStatement)
+		require.True(t, ok)
+
+		floatLiteral, ok := expr.Expression.(*ast.FloatLiteral)
+		require.True(t, ok)
+
+		assert.Equal(t, data.value, floatLiteral.Value)
 	}
+
+	p := New(lexer.New("1e999"))
+	p.ParseProgram()
+	require.Len(t, p.Errors(), 1)
+	assert.Equal(t, "1:1: float literal 1e999 is out of range", p.Errors()[0].Error())
 }
 
 func TestParseBooleanExpression(t *testing.T) {
@@ -78,7 +145,7 @@ func TestIfExpreesion(t *testing.T) {
 			"if (x < y) { x }", "if ((x < y)){x}",
 		},
 		{
-			"if (x < y) { x } else { y }","if ((x < y)){x}{y}",
+			"if (x < y) { x } else { y }", "if ((x < y)){x}else{y}",
 		},
 	}
 
@@ -163,6 +230,98 @@ func TestParseSuffixExpression(t *testing.T) {
 	}
 }
 
+func TestParseConditionalExpression(t *testing.T) {
+	table := []struct {
+		input  string
+		expect string
+	}{
+		{"a ? b : c;", "(a ? b : c)"},
+		{"a ? b : c ? d : e;", "(a ? b : (c ? d : e))"},
+		{"a ? b ? c : d : e;", "(a ? (b ? c : d) : e)"},
+		{"a || b ? c + 1 : d && e;", "((a || b) ? (c + 1) : (d && e))"},
+		{"f(a ? b : c, d);", "f((a ? b : c), d)"},
+		{"(a ? b : c) + 1;", "((a ? b : c) + 1)"},
+		{"c?x:y;", "(c ? x : y)"},
+		{"a?1:2;", "(a ? 1 : 2)"},
+		{"true?1:2;", "((true) ? 1 : 2)"},
+	}
+
+	for _, data := range table {
+		l := lexer.New(data.input)
+		p := New(l)
+		program := p.ParseProgram()
+		require.Equal(t, []error{}, p.Errors(), data.input)
+		require.Equal(t, 1, len(program.Statements))
+
+		assert.Equal(t, data.expect, program.Statements[0].String())
+	}
+
+	p := New(lexer.New("let x = a ? b;"))
+	p.ParseProgram()
+	diags := p.Diagnostics()
+	require.Len(t, diags, 1)
+	assert.Equal(t, "1:14: Expect :, got ;", diags[0].Error())
+	assert.Equal(t, "to match this ?", diags[0].Labels[0].Message)
+}
+
+func TestParseAssignExpression(t *testing.T) {
+	table := []struct {
+		input  string
+		expect string
+	}{
+		{"x = x + 1;", "(x = (x + 1))"},
+		{"x += 2;", "(x += 2)"},
+		{"a = b = c -= 1;", "(a = (b = (c -= 1)))"},
+		{"arr[i] = v;", "((arr[i]) = v)"},
+		{"h[\"k\"] -= 1;", "((h[\"k\"]) -= 1)"},
+		{"m[0][1] *= a || b;", "(((m[0])[1]) *= (a || b))"},
+		{"x /= c ? 1 : 2;", "(x /= (c ? 1 : 2))"},
+		{"c ? x = 1 : y;", "(c ? (x = 1) : y)"},
+		{"f(x = 1);", "f((x = 1))"},
+		{"(x = 1) + 2;", "((x = 1) + 2)"},
+	}
+
+	for _, data := range table {
+		l := lexer.New(data.input)
+		p := New(l)
+		program := p.ParseProgram()
+		require.Equal(t, []error{}, p.Errors(), data.input)
+		require.Equal(t, 1, len(program.Statements))
+
+		assert.Equal(t, data.expect, program.Statements[0].String())
+	}
+
+	invalid := []struct {
+		input string
+		end   int
+	}{
+		{"1 = 2;", 1},
+		{"a + b = 1;", 5},
+		{"f() += 1;", 3},
+		{"a[1:] = b;", 5},
+		{"c ? a : b = 1;", 9},
+	}
+
+	for _, data := range invalid {
+		p := New(lexer.New(data.input))
+		p.ParseProgram()
+		diags := p.Diagnostics()
+		require.Len(t, diags, 1, data.input)
+
+		assert.Equal(t, diagnostic.InvalidAssignment, diags[0].Code)
+		assert.Equal(t, "cannot assign to this expression", diags[0].Message)
+		assert.Equal(t, 0, diags[0].Span.Pos.Offset)
+		assert.Equal(t, data.end, diags[0].Span.End.Offset)
+	}
+
+	// targets that did not finish parsing are reported, not printed
+	for _, input := range []string{"- break = 1", "x + break = 1", "!(x + ) = 1", "f(break) = 1", "[1, break] += 1", "c ? break : 1 = 2", "x + + = 1", "a[break] = 1"} {
+		p := New(lexer.New(input))
+		assert.NotPanics(t, func() { p.ParseProgram() }, input)
+		assert.NotEmpty(t, p.Diagnostics(), input)
+	}
+}
+
 func TestParseInfixExpression(t *testing.T) {
 	table := []struct {
 		input  string
@@ -249,6 +408,33 @@ func TestPrecedenceOperator(t *testing.T) {
 		{
 			"5 > 4 == 3 < 4;", "((5 > 4) == (3 < 4))",
 		},
+		{
+			"-1 + 2;", "((-1) + 2)",
+		},
+		{
+			"!true == false;", "((!(true)) == (false))",
+		},
+		{
+			"a != b == c;", "((a != b) == c)",
+		},
+		{
+			"a!=b;", "(a != b)",
+		},
+		{
+			"!a!=!b;", "((!a) != (!b))",
+		},
+		{
+			"1 + 2 != 3 * 4;", "((1 + 2) != (3 * 4))",
+		},
+		{
+			"a > 0 && b > 0;", "((a > 0) && (b > 0))",
+		},
+		{
+			"a || b && c == d;", "(a || (b && (c == d)))",
+		},
+		{
+			"a && b || c && d || e;", "(((a && b) || (c && d)) || e)",
+		},
 		// {
 		// 	"1 +-+ 2;", "",
 		// },
@@ -270,3 +456,33 @@ func TestPrecedenceOperator(t *testing.T) {
 		assert.Equal(t, data.expect, expr.Expression.String())
 	}
 }
+
+func TestParseStringExpression(t *testing.T) {
+	table := []struct {
+		input string
+		value string
+	}{
+		{
+			`"hello world";`, "hello world",
+		},
+		{
+			`"tab\there\u{21}"`, "tab\there!",
+		},
+	}
+
+	for _, data := range table {
+		l := lexer.New(data.input)
+		p := New(l)
+		program := p.ParseProgram()
+		require.Equal(t, []error{}, p.Errors())
+		require.Equal(t, 1, len(program.Statements))
+
+		expr, ok := (program.Statements[0]).(*ast.ExpressionStatement)
+		require.True(t, ok)
+
+		stringLiteral, ok := expr.Expression.(*ast.StringLiteral)
+		require.True(t, ok)
+
+		assert.Equal(t, data.value, stringLiteral.Value)
+	}
+}
@@ -2,7 +2,9 @@ package parser
 
 import (
 	"compiler/ast"
+	"compiler/diagnostic"
 	"compiler/lexer"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -42,3 +44,440 @@ func testLetStatement(t *testing.T, s ast.Statement, name string) {
 	assert.True(t, ok)
 	assert.Equal(t, name, letStmt.Name.TokenLiteral())
 }
+
+func TestNodePosition(t *testing.T) {
+	input := `1 + 2;
+if (x) {
+  y
+} else { z }`
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	require.Equal(t, 2, len(program.Statements))
+
+	table := []struct {
+		node ast.Node
+		pos  string
+		end  string
+	}{
+		{program.Statements[0], "1:1", "1:6"},
+		{program.Statements[1], "2:1", "4:13"},
+		{program, "1:1", "4:13"},
+	}
+
+	for _, data := range table {
+		assert.Equal(t, data.pos, data.node.Pos().String())
+		assert.Equal(t, data.end, data.node.End().String())
+	}
+
+	ifExpr := program.Statements[1].(*ast.ExpressionStatement).Expression.(*ast.IfExpreesion)
+	assert.Equal(t, "2:8", ifExpr.Consequence.Pos().String())
+	assert.Equal(t, "4:2", ifExpr.Consequence.End().String())
+
+	// the statement keeps the first token of its expression, not the last
+	stmt := program.Statements[0].(*ast.ExpressionStatement)
+	assert.Equal(t, "1", stmt.TokenLiteral())
+	assert.Equal(t, "1:1", stmt.Token.Pos.String())
+}
+
+func TestErrorPosition(t *testing.T) {
+	l := lexer.New("\n  let 5 = 1;", lexer.WithFilename("main.mk"))
+	p := New(l)
+	p.ParseProgram()
+
+	require.NotEmpty(t, p.Errors())
+	assert.Equal(t, "main.mk:2:7: Expect IDENT, got INT", p.Errors()[0].Error())
+}
+
+func TestFnExpression(t *testing.T) {
+	table := []struct {
+		input  string
+		params []string
+		expect string
+	}{
+		{
+			"fn() {}", []string{}, "fn() {}",
+		},
+		{
+			"fn(x) { x }", []string{"x"}, "fn(x) {x}",
+		},
+		{
+			"fn(a, b) { a + b; }", []string{"a", "b"}, "fn(a, b) {(a + b)}",
+		},
+	}
+
+	for _, data := range table {
+		l := lexer.New(data.input)
+		p := New(l)
+		program := p.ParseProgram()
+		require.Equal(t, []error{}, p.Errors())
+		require.Equal(t, 1, len(program.Statements))
+
+		expr, ok := (program.Statements[0]).(*ast.ExpressionStatement)
+		require.True(t, ok)
+
+		fn, ok := expr.Expression.(*ast.FnExpression)
+		require.True(t, ok)
+
+		params := []string{}
+		for _, param := range fn.Param {
+			params = append(params, param.Value)
+		}
+		assert.Equal(t, data.params, params)
+		assert.Equal(t, data.expect, fn.String())
+	}
+}
+
+func TestFnExpressionInLet(t *testing.T) {
+	l := lexer.New("let add = fn(a, b) { a + b };")
+	p := New(l)
+	program := p.ParseProgram()
+	require.Equal(t, []error{}, p.Errors())
+	require.Equal(t, 1, len(program.Statements))
+
+	letStmt, ok := program.Statements[0].(*ast.LetStatement)
+	require.True(t, ok)
+	fn, ok := letStmt.Value.(*ast.FnExpression)
+	require.True(t, ok)
+	assert.Equal(t, "fn(a, b) {(a + b)}", fn.String())
+}
+
+func TestFnExpressionParamError(t *testing.T) {
+	table := []struct {
+		input  string
+		expect string
+	}{
+		{
+			"fn(1) {}", "1:4: Expect IDENT, got INT",
+		},
+		{
+			"fn(x,) {}", "1:6: Expect IDENT, got )",
+		},
+		{
+			"fn(x y) {}", "1:6: Expect ), got IDENT",
+		},
+		{
+			"fn x {}", "1:4: Expect (, got IDENT",
+		},
+	}
+
+	for _, data := range table {
+		l := lexer.New(data.input)
+		p := New(l)
+		p.ParseProgram()
+
+		require.NotEmpty(t, p.Errors(), data.input)
+		assert.Equal(t, data.expect, p.Errors()[0].Error())
+	}
+}
+
+func TestCallExpression(t *testing.T) {
+	table := []struct {
+		input  string
+		expect string
+		args   int
+	}{
+		{
+			"add();", "add()", 0,
+		},
+		{
+			"add(1, 2 * 3, 4 + 5);", "add(1, (2 * 3), (4 + 5))", 3,
+		},
+		{
+			"fn(x) { x }(5)", "fn(x) {x}(5)", 1,
+		},
+		{
+			"f(1)(2)", "f(1)(2)", 1,
+		},
+		{
+			"a + add(b * c) + d", "((a + add((b * c))) + d)", 0,
+		},
+		{
+			"-f(g(1, 2), h)", "(-f(g(1, 2), h))", 0,
+		},
+	}
+
+	for _, data := range table {
+		l := lexer.New(data.input)
+		p := New(l)
+		program := p.ParseProgram()
+		require.Equal(t, []error{}, p.Errors())
+		require.Equal(t, 1, len(program.Statements))
+
+		expr, ok := (program.Statements[0]).(*ast.ExpressionStatement)
+		require.True(t, ok)
+		assert.Equal(t, data.expect, expr.Expression.String())
+
+		if call, ok := expr.Expression.(*ast.CallExpression); ok {
+			assert.Equal(t, data.args, len(call.Arguments))
+			assert.Equal(t, len(strings.TrimSuffix(data.input, ";")), call.End().Offset-call.Pos().Offset)
+		}
+	}
+}
+
+func TestCallExpressionError(t *testing.T) {
+	l := lexer.New("add(1, 2")
+	p := New(l)
+	p.ParseProgram()
+
+	require.NotEmpty(t, p.Errors())
+	assert.Equal(t, "1:9: Expect ), got EOF", p.Errors()[0].Error())
+}
+
+func TestArrayAndIndexExpression(t *testing.T) {
+	table := []struct {
+		input  string
+		expect string
+	}{
+		{"[];", "[]"},
+		{"[1, 2 * 3, \"a\"];", "[1, (2 * 3), \"a\"]"},
+		{"arr[1 + 1];", "(arr[(1 + 1)])"},
+		{"a * [1, 2][b * c] * d", "((a * ([1, 2][(b * c)])) * d)"},
+		{"f(a[0])[1]", "(f((a[0]))[1])"},
+		{"-a[0]", "(-(a[0]))"},
+		{"a[1][-1]", "((a[1])[(-1)])"},
+		{"a[1:3]", "(a[1:3])"},
+		{"a[:n - 1]", "(a[:(n - 1)])"},
+		{"a[1:]", "(a[1:])"},
+		{"a[:]", "(a[:])"},
+		{"a[b ? c : d]", "(a[(b ? c : d)])"},
+	}
+
+	for _, data := range table {
+		l := lexer.New(data.input)
+		p := New(l)
+		program := p.ParseProgram()
+		require.Equal(t, []error{}, p.Errors(), data.input)
+		require.Equal(t, 1, len(program.Statements))
+
+		expr, ok := (program.Statements[0]).(*ast.ExpressionStatement)
+		require.True(t, ok)
+		assert.Equal(t, data.expect, expr.Expression.String())
+		assert.Equal(t, len(strings.TrimSuffix(data.input, ";")), expr.Expression.End().Offset-expr.Expression.Pos().Offset)
+	}
+}
+
+func TestArrayAndIndexExpressionError(t *testing.T) {
+	table := []struct {
+		input  string
+		expect string
+	}{
+		{"[1, 2", "1:6: Expect ], got EOF"},
+		{"a[1", "1:4: Expect ], got EOF"},
+		{"a[]", "1:3: Expect expression, got ]"},
+		{"a[1:2:3]", "1:6: Expect ], got :"},
+	}
+
+	for _, data := range table {
+		p := New(lexer.New(data.input))
+		p.ParseProgram()
+
+		require.NotEmpty(t, p.Errors(), data.input)
+		assert.Equal(t, data.expect, p.Errors()[0].Error())
+	}
+}
+
+func TestHashLiteral(t *testing.T) {
+	table := []struct {
+		input  string
+		expect string
+		pairs  int
+	}{
+		{"{};", "{}", 0},
+		{`{"a": 1, 2: true}`, `{"a": 1, 2: (true)}`, 2},
+		{`{"a" + "b": 1 * 2, k: [1]}`, `{("a" + "b"): (1 * 2), k: [1]}`, 2},
+		{`{"a": {"b": 1}}["a"]`, `({"a": {"b": 1}}["a"])`, 1},
+		{`let h = {true: fn(x) { x }};`, `let h = {(true): fn(x) {x}};`, 1},
+	}
+
+	for _, data := range table {
+		l := lexer.New(data.input)
+		p := New(l)
+		program := p.ParseProgram()
+		require.Equal(t, []error{}, p.Errors(), data.input)
+		require.Equal(t, 1, len(program.Statements))
+		assert.Equal(t, data.expect, program.Statements[0].String())
+
+		var hash *ast.HashLiteral
+		switch stmt := program.Statements[0].(type) {
+		case *ast.LetStatement:
+			hash = stmt.Value.(*ast.HashLiteral)
+		case *ast.ExpressionStatement:
+			if index, ok := stmt.Expression.(*ast.IndexExpression); ok {
+				hash = index.Left.(*ast.HashLiteral)
+			} else {
+				hash = stmt.Expression.(*ast.HashLiteral)
+			}
+		}
+		assert.Equal(t, data.pairs, len(hash.Keys))
+		assert.Equal(t, len(hash.Keys), len(hash.Values))
+	}
+
+	table2 := []struct {
+		input  string
+		expect string
+	}{
+		{`{"a" 1}`, "1:6: Expect :, got INT"},
+		{`{"a": 1`, "1:8: Expect }, got EOF"},
+		{`{"a": 1 "b": 2}`, "1:9: Expect }, got STRING"},
+	}
+
+	for _, data := range table2 {
+		p := New(lexer.New(data.input))
+		p.ParseProgram()
+
+		require.NotEmpty(t, p.Errors(), data.input)
+		assert.Equal(t, data.expect, p.Errors()[0].Error())
+	}
+}
+
+func TestLetStatementValue(t *testing.T) {
+	table := []struct {
+		input  string
+		name   string
+		expect string
+	}{
+		{
+			"let x = 5;", "x", "let x = 5;",
+		},
+		{
+			"let y = a + b * c", "y", "let y = (a + (b * c));",
+		},
+		{
+			"let add = fn(a, b) { return a + b; };", "add", "let add = fn(a, b) {return (a + b);};",
+		},
+	}
+
+	for _, data := range table {
+		l := lexer.New(data.input)
+		p := New(l)
+		program := p.ParseProgram()
+		require.Equal(t, []error{}, p.Errors())
+		require.Equal(t, 1, len(program.Statements))
+
+		testLetStatement(t, program.Statements[0], data.name)
+		assert.NotNil(t, program.Statements[0].(*ast.LetStatement).Value)
+		assert.Equal(t, data.expect, program.Statements[0].String())
+	}
+}
+
+func TestReturnStatement(t *testing.T) {
+	table := []struct {
+		input  string
+		expect string
+	}{
+		{
+			"return 5;", "return 5;",
+		},
+		{
+			"return x + 1", "return (x + 1);",
+		},
+		{
+			"return;", "return;",
+		},
+		{
+			"return", "return;",
+		},
+	}
+
+	for _, data := range table {
+		l := lexer.New(data.input)
+		p := New(l)
+		program := p.ParseProgram()
+		require.Equal(t, []error{}, p.Errors())
+		require.Equal(t, 1, len(program.Statements))
+
+		stmt, ok := program.Statements[0].(*ast.ReturnStatement)
+		require.True(t, ok)
+		assert.Equal(t, data.expect, stmt.String())
+	}
+}
+
+func TestLoopStatement(t *testing.T) {
+	table := []struct {
+		input  string
+		expect string
+	}{
+		{"while (x < 10) { x }", "while ((x < 10)) {x}"},
+		{"while (true) { break; continue }", "while ((true)) {break; continue;}"},
+		{"for (x in [1, 2]) { if (x) { break } }", "for (x in [1, 2]) {if (x){break;}}"},
+		{"for (k in h) { while (k) { continue; } }", "for (k in h) {while (k) {continue;}}"},
+		{"while (a) { fn() { return 1 }; break }", "while (a) {fn() {return 1;}; break;}"},
+	}
+
+	for _, data := range table {
+		l := lexer.New(data.input)
+		p := New(l)
+		program := p.ParseProgram()
+		require.Equal(t, []error{}, p.Errors(), data.input)
+		require.Equal(t, 1, len(program.Statements))
+		assert.Equal(t, data.expect, program.Statements[0].String())
+		assert.Equal(t, len(data.input), program.Statements[0].End().Offset)
+	}
+}
+
+func TestLoopStatementError(t *testing.T) {
+	table := []struct {
+		input  string
+		expect string
+	}{
+		{"break;", "1:1: break is not in a loop"},
+		{"if (a) { continue }", "1:10: continue is not in a loop"},
+		{"while (a) { fn() { break } }", "1:20: break is not in a loop"},
+		{"while a { }", "1:7: Expect (, got IDENT"},
+		{"for (1 in a) { }", "1:6: Expect IDENT, got INT"},
+		{"for (x of a) { }", "1:8: Expect IN, got IDENT"},
+		{"for (x in a { }", "1:13: Expect ), got {"},
+	}
+
+	for _, data := range table {
+		p := New(lexer.New(data.input))
+		p.ParseProgram()
+
+		require.NotEmpty(t, p.Errors(), data.input)
+		assert.Equal(t, data.expect, p.Errors()[0].Error())
+	}
+
+	p := New(lexer.New("break; let x = 1;"))
+	program := p.ParseProgram()
+	assert.Equal(t, diagnostic.OutsideLoop, p.Diagnostics()[0].Code)
+	require.Equal(t, 1, len(program.Statements))
+	assert.Equal(t, "let x = 1;", program.Statements[0].String())
+}
+
+func TestRoundTrip(t *testing.T) {
+	table := []string{
+		"let x = 5; let y = x * 2\nreturn x + y",
+		"let f = fn(a, b) { let c = a + b; return c } f(1, 2)",
+		`if (x < y) { x; y } else { return; } "a\tb"`,
+		"-a * b++; !(a == b); fn() { fn() {} }()(1)",
+		"let x = fn(n) { if (n < 1) { return 0 } n }(3)",
+	}
+
+	for _, input := range table {
+		program := New(lexer.New(input)).ParseProgram()
+		output := program.String()
+
+		p := New(lexer.New(output))
+		reparsed := p.ParseProgram()
+		require.Equal(t, []error{}, p.Errors(), output)
+		assert.Equal(t, len(program.Statements), len(reparsed.Statements), output)
+		assert.Equal(t, output, reparsed.String())
+	}
+}
+
+func TestSkipComments(t *testing.T) {
+	input := `// add two numbers
+let add = fn(a, /* first */ b) {
+  a + b // sum
+};
+add(1, 2) /* done */`
+
+	for _, l := range []*lexer.Lexer{lexer.New(input), lexer.New(input, lexer.WithComments())} {
+		p := New(l)
+		program := p.ParseProgram()
+		require.Equal(t, []error{}, p.Errors())
+		assert.Equal(t, "let add = fn(a, b) {(a + b)};\nadd(1, 2)", program.String())
+	}
+}
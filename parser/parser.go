@@ -2,15 +2,23 @@ package parser
 
 import (
 	"compiler/ast"
+	"compiler/diagnostic"
 	"compiler/lexer"
 	"compiler/token"
-	"fmt"
+	"sort"
 )
 
 // take token from lexer, then parse to ast
 type Parser struct {
-	l      *lexer.Lexer
-	errors []error
+	l           *lexer.Lexer
+	diagnostics []*diagnostic.Diagnostic
+	// errors already handled by synchronize, so enclosing statements don't recover again
+	recovered int
+	// loops around curToken within the current function, break and continue need one
+	loopDepth int
+	// lexer diagnostics are moved into diagnostics once their token becomes curToken
+	peekDiags    []*diagnostic.Diagnostic
+	curDiagStart int
 
 	curToken  token.Token
 	peekToken token.Token
@@ -21,12 +29,12 @@ type Parser struct {
 
 func New(l *lexer.Lexer) *Parser {
 	p := &Parser{
-		l:          l,
-		errors:     []error{},
-		curToken:   token.Token{},
-		peekToken:  token.Token{},
-		stmtParser: &StmtParser{},
-		exprParser: &ExprParser{},
+		l:           l,
+		diagnostics: []*diagnostic.Diagnostic{},
+		curToken:    token.Token{},
+		peekToken:   token.Token{},
+		stmtParser:  &StmtParser{},
+		exprParser:  &ExprParser{},
 	}
 	p.stmtParser = &StmtParser{
 		Parser: p,
@@ -54,25 +62,91 @@ func (p *Parser) ParseProgram() *ast.Program {
 	return program
 }
 
+// parse one statement, a malformed one is dropped and the parser
+// skips ahead to the next statement boundary
 func (p *Parser) parseStatement() ast.Statement {
+	// NOTE: count lexer errors of the first token as part of this statement
+	errCount := p.curDiagStart
+
+	var stmt ast.Statement
 	switch p.curToken.Type {
+	case token.SEMICOLON:
+		// empty statement
+		return nil
 	case token.LET:
-		return p.stmtParser.parsetLetStatement()
+		stmt = p.stmtParser.parsetLetStatement()
 	// case token.IF:
 	// 	return p.stmtParser.parseIfStatement()
 	case token.RETURN:
-		return p.stmtParser.parseReturnStatement()
-	case token.FUNCTION:
-		return p.stmtParser.parseFunctionStatement()
+		stmt = p.stmtParser.parseReturnStatement()
+	case token.WHILE:
+		stmt = p.stmtParser.parseWhileStatement()
+	case token.FOR:
+		stmt = p.stmtParser.parseForStatement()
+	case token.BREAK, token.CONTINUE:
+		stmt = p.stmtParser.parseLoopControlStatement()
 	default:
-		return p.stmtParser.parseExpressionStatement(LOWEST)
+		stmt = p.stmtParser.parseExpressionStatement(LOWEST)
+	}
+
+	if len(p.diagnostics) > errCount && len(p.diagnostics) > p.recovered {
+		p.synchronize()
+		p.recovered = len(p.diagnostics)
+		return nil
+	}
+	return stmt
+}
+
+// tokens that begin a new statement, used to resynchronize after an error
+var statementStarts = map[token.TokenType]bool{
+	token.LET:    true,
+	token.RETURN: true,
+	token.IF:     true,
+	token.WHILE:  true,
+	token.FOR:    true,
+}
+
+// panic mode: skip tokens until curToken ends the broken statement, that is
+// curToken is ; or the } closing the enclosing block, or the next token is
+// } EOF or starts a statement. nested blocks are skipped as a whole
+func (p *Parser) synchronize() {
+	depth := 0
+	for {
+		switch p.curToken.Type {
+		case token.LBRACE:
+			depth++
+		case token.RBRACE:
+			depth--
+		}
+
+		if depth < 0 || p.curTokenIs(token.EOF) {
+			return
+		}
+		if depth == 0 {
+			if p.curTokenIs(token.SEMICOLON) ||
+				p.peekTokenIs(token.RBRACE) ||
+				p.peekTokenIs(token.EOF) ||
+				statementStarts[p.peekToken.Type] {
+				return
+			}
+		}
+		p.nextToken()
 	}
 }
 
 // some helper junctions
 func (p *Parser) nextToken() {
 	p.curToken = p.peekToken
+	p.curDiagStart = len(p.diagnostics)
+	p.diagnostics = append(p.diagnostics, p.peekDiags...)
+
+	lexed := len(p.l.Diagnostics())
 	p.peekToken = p.l.NextToken()
+	// the lexer may be keeping comments for other tools
+	for p.peekToken.Type == token.COMMENT {
+		p.peekToken = p.l.NextToken()
+	}
+	p.peekDiags = p.l.Diagnostics()[lexed:]
 }
 
 func (p *Parser) curTokenIs(t token.TokenType) bool {
@@ -94,12 +168,50 @@ func (p *Parser) expectPeek(t token.TokenType) bool {
 	}
 }
 
+// like expectPeek, but point back at the token t should close
+func (p *Parser) expectClosing(t token.TokenType, open token.Token) bool {
+	if p.expectPeek(t) {
+		return true
+	}
+	p.lastDiagnostic().WithLabel(diagnostic.TokenSpan(open), "to match this %v", open.Type)
+	return false
+}
+
 // error handling
+// lexer and parser diagnostics ordered by position
+func (p *Parser) Diagnostics() []*diagnostic.Diagnostic {
+	diags := append([]*diagnostic.Diagnostic{}, p.diagnostics...)
+	diags = append(diags, p.peekDiags...)
+	sort.SliceStable(diags, func(i, j int) bool {
+		return diags[i].Span.Pos.Offset < diags[j].Span.Pos.Offset
+	})
+	return diags
+}
+
 func (p *Parser) Errors() []error {
-	return p.errors
+	errors := []error{}
+	for _, d := range p.Diagnostics() {
+		errors = append(errors, d)
+	}
+	return errors
+}
+
+func (p *Parser) addDiagnostic(d *diagnostic.Diagnostic) *diagnostic.Diagnostic {
+	p.diagnostics = append(p.diagnostics, d)
+	return d
 }
 
-func (p *Parser) addPeekError(t token.TokenType) {
-	err := fmt.Errorf("Expect %v, got %v", t, p.peekToken.Type)
-	p.errors = append(p.errors, err)
+func (p *Parser) lastDiagnostic() *diagnostic.Diagnostic {
+	return p.diagnostics[len(p.diagnostics)-1]
+}
+
+func (p *Parser) addPeekError(t token.TokenType) *diagnostic.Diagnostic {
+	// the lexer already explained what is wrong with an ILLEGAL token
+	if p.peekTokenIs(token.ILLEGAL) && len(p.peekDiags) != 0 {
+		p.diagnostics = append(p.diagnostics, p.peekDiags...)
+		p.peekDiags = nil
+		return p.lastDiagnostic()
+	}
+	return p.addDiagnostic(diagnostic.Errorf(diagnostic.UnexpectedToken,
+		diagnostic.TokenSpan(p.peekToken), "Expect %v, got %v", t, p.peekToken.Type))
 }
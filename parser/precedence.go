@@ -1,10 +1,18 @@
 package parser
 
-import "compiler/token"
+import (
+	"compiler/token"
+	"sort"
+	"strings"
+)
 
 const (
 	_ int = iota
 	LOWEST
+	ASSIGN
+	CONDITIONAL
+	OR
+	AND
 	EQUAL
 	LESSGREATER
 	SUM
@@ -12,33 +20,42 @@ const (
 	PREFIX
 	SUFFIX
 	CALL
-	WHAT
+	INDEX
 	LPAREN
 )
 
 var precedencs = map[token.TokenType]int{
 	// Operators
-	token.ASSIGN:     EQUAL,
-	token.EQ:         EQUAL,
-	token.NE:         EQUAL,
-	token.PLUS:       SUM,
-	token.PLUSPLUS:   SUFFIX,
-	token.MINUS:      SUM,
-	token.MINUSMINUS: SUFFIX,
-	token.MULTI:      PRODUCT,
-	token.DIVIDE:     PRODUCT,
-	token.BANG:       PREFIX,
-	token.WHAT:       SUFFIX,
-	token.LT:         LESSGREATER,
-	token.LE:         LESSGREATER,
-	token.GT:         LESSGREATER,
-	token.GE:         LESSGREATER,
-	token.LPAREN:     LPAREN,
-	token.RPAREN:     LOWEST,
-	token.LBRACE:     LPAREN,
-	token.RBRACE:     LOWEST,
-	token.COMMA:      LPAREN,
-	token.SEMICOLON:  LOWEST,
+	token.ASSIGN:        ASSIGN,
+	token.PLUS_ASSIGN:   ASSIGN,
+	token.MINUS_ASSIGN:  ASSIGN,
+	token.MULTI_ASSIGN:  ASSIGN,
+	token.DIVIDE_ASSIGN: ASSIGN,
+	token.EQ:            EQUAL,
+	token.NE:            EQUAL,
+	token.PLUS:          SUM,
+	token.PLUSPLUS:      SUFFIX,
+	token.MINUS:         SUM,
+	token.MINUSMINUS:    SUFFIX,
+	token.MULTI:         PRODUCT,
+	token.DIVIDE:        PRODUCT,
+	token.BANG:          PREFIX,
+	token.WHAT:          CONDITIONAL,
+	token.LT:            LESSGREATER,
+	token.LE:            LESSGREATER,
+	token.GT:            LESSGREATER,
+	token.GE:            LESSGREATER,
+	token.AND:           AND,
+	token.OR:            OR,
+	token.LPAREN:        CALL,
+	token.LBRACKET:      INDEX,
+	token.RBRACKET:      LOWEST,
+	token.RPAREN:        LOWEST,
+	token.LBRACE:        LPAREN,
+	token.RBRACE:        LOWEST,
+	token.COMMA:         LOWEST,
+	token.COLON:         LOWEST,
+	token.SEMICOLON:     LOWEST,
 }
 
 func findPrecedence(t token.TokenType) int {
@@ -48,3 +65,23 @@ func findPrecedence(t token.TokenType) int {
 		return LOWEST
 	}
 }
+
+func sortTokenTypes(ts []token.TokenType) []token.TokenType {
+	sort.Slice(ts, func(i, j int) bool {
+		return ts[i] < ts[j]
+	})
+	return ts
+}
+
+func joinTokenTypes(ts []token.TokenType) string {
+	ss := []string{}
+	for _, t := range ts {
+		ss = append(ss, string(t))
+	}
+	return strings.Join(ss, " ")
+}
+
+// how tightly t binds as an infix or suffix operator, LOWEST if it is neither
+func Precedence(t token.TokenType) int {
+	return findPrecedence(t)
+}
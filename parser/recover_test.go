@@ -0,0 +1,203 @@
+package parser
+
+import (
+	"compiler/diagnostic"
+	"compiler/lexer"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecoverFromErrors(t *testing.T) {
+	table := []struct {
+		input      string
+		errors     []string
+		statements []string
+	}{
+		{
+			"let = 5; let y = 1;",
+			[]string{"1:5: Expect IDENT, got ="},
+			[]string{"let y = 1;"},
+		},
+		{
+			"let x 5\nlet y = 1 + ;\nlet z = 3",
+			[]string{
+				"1:7: Expect =, got INT",
+				"2:13: Expect expression, got ;",
+			},
+			[]string{"let z = 3;"},
+		},
+		{
+			"let x = 5",
+			[]string{},
+			[]string{"let x = 5;"},
+		},
+		{
+			"-; x; !(1 + ); y; 1 + -; z",
+			[]string{
+				"1:2: Expect expression, got ;",
+				"1:13: Expect expression, got )",
+				"1:24: Expect expression, got ;",
+			},
+			[]string{"x", "y", "z"},
+		},
+		{
+			"if (a) { 1 + } b + c",
+			[]string{"1:14: Expect expression, got }"},
+			[]string{"if (a){}", "(b + c)"},
+		},
+		{
+			"let f = fn(x { x }; return f(1);",
+			[]string{"1:14: Expect ), got {"},
+			[]string{"return f(1);"},
+		},
+		{
+			"fn() { let 1; x }; y",
+			[]string{"1:12: Expect IDENT, got INT"},
+			[]string{"fn() {x}", "y"},
+		},
+		{
+			"if (x { 1 } let a = 2;",
+			[]string{"1:7: Expect ), got {"},
+			[]string{"let a = 2;"},
+		},
+		{
+			"fn() { x",
+			[]string{"1:9: Expect }, got EOF"},
+			[]string{},
+		},
+		{
+			"let x = 1;; x",
+			[]string{},
+			[]string{"let x = 1;", "x"},
+		},
+		{
+			") } let a = 1",
+			[]string{
+				"1:1: Expect expression, got )",
+				"1:3: Expect expression, got }",
+			},
+			[]string{"let a = 1;"},
+		},
+	}
+
+	for _, data := range table {
+		p := New(lexer.New(data.input))
+		program := p.ParseProgram()
+
+		errors := []string{}
+		for _, err := range p.Errors() {
+			errors = append(errors, err.Error())
+		}
+		assert.Equal(t, data.errors, errors, data.input)
+
+		statements := []string{}
+		for _, stmt := range program.Statements {
+			statements = append(statements, stmt.String())
+		}
+		assert.Equal(t, data.statements, statements, data.input)
+	}
+}
+
+func TestDiagnostics(t *testing.T) {
+	p := New(lexer.New("let x = (1 + 2;\nlet s = \"a\";\nfn() {"))
+	p.ParseProgram()
+
+	diags := p.Diagnostics()
+	assert.Equal(t, 2, len(diags))
+
+	assert.Equal(t, diagnostic.UnexpectedToken, diags[0].Code)
+	assert.Equal(t, "1:15: Expect ), got ;", diags[0].Error())
+	assert.Equal(t, 1, len(diags[0].Labels))
+	assert.Equal(t, "1:9", diags[0].Labels[0].Span.Pos.String())
+
+	assert.Equal(t, diagnostic.UnclosedBlock, diags[1].Code)
+	assert.Equal(t, "3:7: Expect }, got EOF", diags[1].Error())
+	assert.Equal(t, "block opened here", diags[1].Labels[0].Message)
+}
+
+func TestUnexpectedTokenDiagnostics(t *testing.T) {
+	table := []struct {
+		input   string
+		message string
+		note    string
+	}{
+		{
+			"let x = ;",
+			"1:9: Expect expression, got ;",
+			"expected one of: ! ( - FALSE FLOAT FUNCTION IDENT IF INT STRING TRUE [ {",
+		},
+		{
+			"(1 2)",
+			"1:4: Expect ), got INT",
+			"expected one of: ) != && ( * *= + ++ += - -- -= / /= < <= = == > >= ? [ ||",
+		},
+		{
+			"f(1 2)",
+			"1:5: Expect ), got INT",
+			"expected one of: ) , != && ( * *= + ++ += - -- -= / /= < <= = == > >= ? [ ||",
+		},
+	}
+
+	for _, data := range table {
+		p := New(lexer.New(data.input))
+		p.ParseProgram()
+
+		diags := p.Diagnostics()
+		if assert.Equal(t, 1, len(diags), data.input) {
+			assert.Equal(t, data.message, diags[0].Error())
+			assert.Equal(t, []string{data.note}, diags[0].Notes)
+		}
+	}
+}
+
+func TestIllegalTokenDiagnostics(t *testing.T) {
+	table := []struct {
+		input      string
+		errors     []string
+		statements []string
+	}{
+		{
+			"let a = 1 @ 2; let b = 2;",
+			[]string{`1:11: illegal character "@"`},
+			[]string{"let a = 1;", "let b = 2;"},
+		},
+		{
+			"let s = \"a\\qb\"; let t = 1",
+			[]string{`1:11: unknown escape sequence \q`},
+			[]string{"let t = 1;"},
+		},
+		{
+			"f(1, #)",
+			[]string{`1:6: illegal character "#"`},
+			[]string{},
+		},
+		{
+			"(1 $",
+			[]string{`1:4: illegal character "$"`},
+			[]string{},
+		},
+		{
+			"let x = 1\n\"open",
+			[]string{"2:1: unterminated string literal"},
+			[]string{"let x = 1;"},
+		},
+	}
+
+	for _, data := range table {
+		p := New(lexer.New(data.input))
+		program := p.ParseProgram()
+
+		errors := []string{}
+		for _, err := range p.Errors() {
+			errors = append(errors, err.Error())
+		}
+		assert.Equal(t, data.errors, errors, data.input)
+
+		statements := []string{}
+		for _, stmt := range program.Statements {
+			statements = append(statements, stmt.String())
+		}
+		assert.Equal(t, data.statements, statements, data.input)
+	}
+}
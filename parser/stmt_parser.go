@@ -2,6 +2,7 @@ package parser
 
 import (
 	"compiler/ast"
+	"compiler/diagnostic"
 	"compiler/token"
 )
 
@@ -11,9 +12,9 @@ type StmtParser struct {
 
 func (p *StmtParser) parseExpressionStatement(precedence int) ast.Statement {
 	stmt := &ast.ExpressionStatement{
-		Token:      p.curToken,
-		Expression: p.exprParser.ParseExpreesion(LOWEST),
+		Token: p.curToken,
 	}
+	stmt.Expression = p.exprParser.ParseExpreesion(LOWEST)
 
 	if p.peekTokenIs(token.SEMICOLON) {
 		p.nextToken()
@@ -23,16 +24,14 @@ func (p *StmtParser) parseExpressionStatement(precedence int) ast.Statement {
 }
 
 func (p *StmtParser) parsetLetStatement() ast.Statement {
-	// let <identifier> = <expression>
+	// let <identifier> = <expression>[;]
 	stmt := &ast.LetStatement{
 		Token: p.curToken,
 		Name:  nil,
 		Value: nil,
 	}
 
-	print(p.peekToken.Literal)
 	if !p.expectPeek(token.IDENT) {
-		p.addPeekError(p.peekToken.Type)
 		return nil
 	}
 
@@ -41,15 +40,14 @@ func (p *StmtParser) parsetLetStatement() ast.Statement {
 		Value: p.curToken.Literal,
 	}
 
-	// do noting with assign
 	if !p.expectPeek(token.ASSIGN) {
 		return nil
 	}
 
-	// TODO(dingwang): parse expression
+	p.nextToken()
 	stmt.Value = p.exprParser.ParseExpreesion(LOWEST)
 
-	for !p.curTokenIs(token.SEMICOLON) {
+	if p.peekTokenIs(token.SEMICOLON) {
 		p.nextToken()
 	}
 
@@ -57,24 +55,116 @@ func (p *StmtParser) parsetLetStatement() ast.Statement {
 }
 
 func (p *StmtParser) parseReturnStatement() ast.Statement {
-	// return <expression>
+	// return [<expression>][;]
 	stmt := &ast.ReturnStatement{
 		Token: p.curToken,
 		Value: nil,
 	}
+
+	// a bare return ends at ; } or EOF
+	if p.peekTokenIs(token.SEMICOLON) || p.peekTokenIs(token.RBRACE) || p.peekTokenIs(token.EOF) {
+		if p.peekTokenIs(token.SEMICOLON) {
+			p.nextToken()
+		}
+		return stmt
+	}
+
 	p.nextToken()
-	// TODO(dingwang): parse expression
-	for !p.curTokenIs(token.SEMICOLON) {
+	stmt.Value = p.exprParser.ParseExpreesion(LOWEST)
+
+	if p.peekTokenIs(token.SEMICOLON) {
 		p.nextToken()
 	}
+
 	return stmt
 }
 
-func (p *StmtParser) parseIfStatement() ast.Statement {
-	return nil
+// while (<expression>) <block>
+func (p *StmtParser) parseWhileStatement() ast.Statement {
+	stmt := &ast.WhileStatement{
+		Token: p.curToken,
+	}
+
+	if !p.expectPeek(token.LPAREN) {
+		return nil
+	}
+	lparen := p.curToken
+	p.nextToken()
+	stmt.Condition = p.exprParser.ParseExpreesion(LOWEST)
+	if stmt.Condition == nil || !p.exprParser.expectAfterExpression(token.RPAREN, lparen) {
+		return nil
+	}
+
+	if !p.expectPeek(token.LBRACE) {
+		return nil
+	}
+	stmt.Body = p.parseLoopBody()
+
+	return stmt
 }
 
-func (p *StmtParser) parseFunctionStatement() ast.Statement {
+// for (<identifier> in <expression>) <block>
+func (p *StmtParser) parseForStatement() ast.Statement {
+	stmt := &ast.ForStatement{
+		Token: p.curToken,
+	}
+
+	if !p.expectPeek(token.LPAREN) {
+		return nil
+	}
+	lparen := p.curToken
+	if !p.expectPeek(token.IDENT) {
+		return nil
+	}
+	stmt.Variable = &ast.Identifier{
+		Token: p.curToken,
+		Value: p.curToken.Literal,
+	}
+	if !p.expectPeek(token.IN) {
+		return nil
+	}
+
+	p.nextToken()
+	stmt.Iterable = p.exprParser.ParseExpreesion(LOWEST)
+	if stmt.Iterable == nil || !p.exprParser.expectAfterExpression(token.RPAREN, lparen) {
+		return nil
+	}
+
+	if !p.expectPeek(token.LBRACE) {
+		return nil
+	}
+	stmt.Body = p.parseLoopBody()
+
+	return stmt
+}
+
+func (p *StmtParser) parseLoopBody() *ast.BlockStatement {
+	p.loopDepth++
+	defer func() { p.loopDepth-- }()
+	return p.parseBlockStatement()
+}
+
+// break[;] or continue[;], only inside a loop of the same function
+func (p *StmtParser) parseLoopControlStatement() ast.Statement {
+	var stmt ast.Statement
+	if p.curTokenIs(token.BREAK) {
+		stmt = &ast.BreakStatement{Token: p.curToken}
+	} else {
+		stmt = &ast.ContinueStatement{Token: p.curToken}
+	}
+
+	if p.loopDepth == 0 {
+		p.addDiagnostic(diagnostic.Errorf(diagnostic.OutsideLoop,
+			diagnostic.TokenSpan(p.curToken), "%s is not in a loop", p.curToken.Literal))
+	}
+
+	if p.peekTokenIs(token.SEMICOLON) {
+		p.nextToken()
+	}
+	return stmt
+}
+
+func (p *StmtParser) parseIfStatement() ast.Statement {
 	return nil
 }
 
@@ -88,11 +178,20 @@ func (p *StmtParser) parseBlockStatement() *ast.BlockStatement {
 
 	for !p.curTokenIs(token.RBRACE) && !p.curTokenIs(token.EOF) {
 		stmt := p.parseStatement()
-		if b != nil {
+		if stmt != nil {
 			b.Statements = append(b.Statements, stmt)
+		} else if p.curTokenIs(token.RBRACE) {
+			// the broken statement ran into the end of this block
+			break
 		}
 		p.nextToken()
 	}
+	if p.curTokenIs(token.EOF) {
+		p.addDiagnostic(diagnostic.Errorf(diagnostic.UnclosedBlock,
+			diagnostic.TokenSpan(p.curToken), "Expect }, got EOF").
+			WithLabel(diagnostic.TokenSpan(b.Token), "block opened here"))
+	}
+	b.Rbrace = p.curToken
 
 	return b
 }
@@ -0,0 +1,122 @@
+package repl
+
+import (
+	"compiler/ast"
+	"compiler/diagnostic"
+	"compiler/lexer"
+	"compiler/object"
+	"compiler/parser"
+	"compiler/token"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+const commandPrefix = ":"
+
+type command struct {
+	usage string
+	help  string
+	// return true to end the session
+	run func(s *session, arg string) bool
+}
+
+var commands map[string]command
+
+func init() {
+	// NOTE: assigned in init, :help refers back to commands
+	commands = map[string]command{
+		"tokens": {":tokens <expr>", "print the tokens of <expr>", (*session).tokensCommand},
+		"ast":    {":ast <expr>", "print the syntax tree of <expr>", (*session).astCommand},
+		"env":    {":env", "list the bindings of the session", (*session).envCommand},
+		"load":   {":load <file>", "evaluate a file into the session", (*session).loadCommand},
+		"reset":  {":reset", "drop all bindings", (*session).resetCommand},
+		"quit":   {":quit", "leave the REPL", (*session).quitCommand},
+		"help":   {":help", "show this help", (*session).helpCommand},
+	}
+}
+
+func isCommand(src string) bool {
+	return strings.HasPrefix(strings.TrimSpace(src), commandPrefix)
+}
+
+// run a meta command like ":ast 1 + 2", return true to end the session
+func (s *session) command(src string) bool {
+	line := strings.TrimPrefix(strings.TrimSpace(src), commandPrefix)
+	name, arg, _ := strings.Cut(line, " ")
+	arg = strings.TrimSpace(arg)
+
+	cmd, ok := commands[name]
+	if !ok {
+		fmt.Fprintf(s.out, "unknown command %s%s, type :help for a list\n", commandPrefix, name)
+		return false
+	}
+	return cmd.run(s, arg)
+}
+
+func (s *session) tokensCommand(arg string) bool {
+	l := lexer.New(arg, lexer.WithFilename(replFilename), lexer.WithComments())
+	for tok := l.NextToken(); tok.Type != token.EOF; tok = l.NextToken() {
+		fmt.Fprintf(s.out, "%d:%d\t%v\t%q\n", tok.Pos.Line, tok.Pos.Column, tok.Type, tok.Literal)
+	}
+	diagnostic.RenderAll(s.out, arg, l.Diagnostics())
+	return false
+}
+
+func (s *session) astCommand(arg string) bool {
+	p := parser.New(lexer.New(arg, lexer.WithFilename(replFilename)))
+	program := p.ParseProgram()
+	if diags := p.Diagnostics(); len(diags) != 0 {
+		diagnostic.RenderAll(s.out, arg, diags)
+		return false
+	}
+	for _, stmt := range program.Statements {
+		ast.Fprint(s.out, stmt)
+	}
+	return false
+}
+
+func (s *session) envCommand(arg string) bool {
+	for _, name := range s.env.Names() {
+		val, _ := s.env.Get(name)
+		fmt.Fprintf(s.out, "%s = %s\n", name, val.Inspect())
+	}
+	return false
+}
+
+func (s *session) loadCommand(arg string) bool {
+	if arg == "" {
+		fmt.Fprintln(s.out, "usage: "+commands["load"].usage)
+		return false
+	}
+	src, err := os.ReadFile(arg)
+	if err != nil {
+		fmt.Fprintf(s.out, "load: %v\n", err)
+		return false
+	}
+	s.eval(string(src), arg)
+	return false
+}
+
+func (s *session) resetCommand(arg string) bool {
+	s.env = object.NewEnvironment()
+	return false
+}
+
+func (s *session) quitCommand(arg string) bool {
+	return true
+}
+
+func (s *session) helpCommand(arg string) bool {
+	names := []string{}
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		fmt.Fprintf(s.out, "%-16s %s\n", commands[name].usage, commands[name].help)
+	}
+	return false
+}
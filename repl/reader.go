@@ -0,0 +1,40 @@
+package repl
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// returned by ReadLine when the user cancels the current input
+var errInterrupted = errors.New("interrupted")
+
+// source of input lines, io.EOF ends the session
+type lineReader interface {
+	ReadLine(prompt string) (string, error)
+}
+
+// plain reader without editing, for pipes and tests
+type scannerReader struct {
+	scanner *bufio.Scanner
+	out     io.Writer
+}
+
+func newScannerReader(in io.Reader, out io.Writer) *scannerReader {
+	return &scannerReader{
+		scanner: bufio.NewScanner(in),
+		out:     out,
+	}
+}
+
+func (r *scannerReader) ReadLine(prompt string) (string, error) {
+	fmt.Fprint(r.out, prompt)
+	if !r.scanner.Scan() {
+		if err := r.scanner.Err(); err != nil {
+			return "", err
+		}
+		return "", io.EOF
+	}
+	return r.scanner.Text(), nil
+}
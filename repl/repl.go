@@ -1,32 +1,134 @@
 package repl
 
 import (
-	"bufio"
+	"compiler/diagnostic"
+	"compiler/evaluator"
 	"compiler/lexer"
+	"compiler/object"
+	"compiler/parser"
 	"compiler/token"
 	"fmt"
 	"io"
+	"strings"
 )
 
 const PROMPT = "QWQ >> "
 
+// shown while the input so far is incomplete
+const CONTINUE_PROMPT = "... >> "
+
+// file name shown in diagnostics of REPL input
+const replFilename = "<repl>"
+
 func Start(in io.Reader, out io.Writer) {
-	scanner := bufio.NewScanner(in)
+	run(newScannerReader(in, out), newSession(out))
+}
 
+func run(reader lineReader, s *session) {
 	for {
-		fmt.Print(PROMPT)
-
-		scanned := scanner.Scan()
-		if !scanned {
+		src, ok := readInput(reader)
+		if !ok {
 			return
 		}
+		if strings.TrimSpace(src) == "" {
+			continue
+		}
+
+		if isCommand(src) {
+			if quit := s.command(src); quit {
+				return
+			}
+			continue
+		}
+		s.eval(src, replFilename)
+	}
+}
+
+// read lines until they form a complete program.
+// an empty line submits what is there, so mistakes don't trap the user
+func readInput(reader lineReader) (string, bool) {
+	lines := []string{}
+
+	for {
+		prompt := PROMPT
+		if len(lines) != 0 {
+			prompt = CONTINUE_PROMPT
+		}
+
+		line, err := reader.ReadLine(prompt)
+		if err == errInterrupted {
+			// drop the input so far and start over
+			return "", true
+		}
+		if err != nil {
+			// submit what we have at the end of input
+			return strings.Join(lines, "\n"), len(lines) != 0
+		}
+
+		if len(lines) != 0 && strings.TrimSpace(line) == "" {
+			return strings.Join(lines, "\n"), true
+		}
+		lines = append(lines, line)
 
-		line := scanner.Text()
+		src := strings.Join(lines, "\n")
+		// meta commands are always one line
+		if isCommand(src) || isComplete(src) {
+			return src, true
+		}
+	}
+}
 
-		l := lexer.New(line)
+// input is incomplete if a ( { or /* is still open, or the parser
+// ran into the end of input while it expected more
+func isComplete(src string) bool {
+	depth := 0
+	l := lexer.New(src)
+	for tok := l.NextToken(); tok.Type != token.EOF; tok = l.NextToken() {
+		switch tok.Type {
+		case token.LPAREN, token.LBRACE, token.LBRACKET:
+			depth++
+		case token.RPAREN, token.RBRACE, token.RBRACKET:
+			depth--
+		}
+	}
+	if depth > 0 {
+		return false
+	}
 
-		for tok := l.NextToken(); tok.Type != token.EOF; tok = l.NextToken() {
-			fmt.Fprintf(out, "%+v\n", tok)
+	p := parser.New(lexer.New(src))
+	p.ParseProgram()
+	for _, d := range p.Diagnostics() {
+		if d.Span.Pos.Offset >= len(src) || d.Code == diagnostic.UnterminatedComment {
+			return false
 		}
 	}
+	return true
+}
+
+// state kept between inputs
+type session struct {
+	out io.Writer
+	// bindings live for the whole session
+	env *object.Environment
+}
+
+func newSession(out io.Writer) *session {
+	return &session{
+		out: out,
+		env: object.NewEnvironment(),
+	}
+}
+
+func (s *session) eval(src string, filename string) {
+	p := parser.New(lexer.New(src, lexer.WithFilename(filename)))
+	program := p.ParseProgram()
+	if diags := p.Diagnostics(); len(diags) != 0 {
+		diagnostic.RenderAll(s.out, src, diags)
+		return
+	}
+
+	evaluated := evaluator.Eval(program, s.env)
+	if evaluated != nil {
+		fmt.Fprintln(s.out, evaluated.Inspect())
+	}
 }
@@ -0,0 +1,192 @@
+package repl
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStart(t *testing.T) {
+	input := `let x = 5;
+let add = fn(a, b) { a + b };
+add(x, 10)
+"a" + "b"
+y
+let = 1
+x * 2
+`
+
+	var out bytes.Buffer
+	Start(strings.NewReader(input), &out)
+
+	expect := PROMPT +
+		PROMPT +
+		PROMPT + "15\n" +
+		PROMPT + "ab\n" +
+		PROMPT + "ERROR: identifier not found: y\n" +
+		PROMPT + "error[E0101]: Expect IDENT, got =\n" +
+		" --> <repl>:1:5\n" +
+		"  |\n" +
+		"1 | let = 1\n" +
+		"  |     ^\n" +
+		PROMPT + "10\n" +
+		PROMPT
+	assert.Equal(t, expect, out.String())
+}
+
+func TestStartMultiLine(t *testing.T) {
+	input := `let add = fn(a, b) {
+  a + b
+};
+add(1,
+  2)
+let x = 1 +
+2
+if (x > 2) {
+
+3 +
+`
+
+	var out bytes.Buffer
+	Start(strings.NewReader(input), &out)
+
+	expect := PROMPT + CONTINUE_PROMPT + CONTINUE_PROMPT +
+		PROMPT + CONTINUE_PROMPT + "3\n" +
+		PROMPT + CONTINUE_PROMPT +
+		PROMPT + CONTINUE_PROMPT + "error[E0103]: Expect }, got EOF\n" +
+		" --> <repl>:1:13\n" +
+		"  |\n" +
+		"1 | if (x > 2) {\n" +
+		"  |             ^\n" +
+		"  |            - block opened here\n" +
+		PROMPT + CONTINUE_PROMPT + "error[E0102]: Expect expression, got EOF\n" +
+		" --> <repl>:1:4\n" +
+		"  |\n" +
+		"1 | 3 +\n" +
+		"  |    ^\n" +
+		"  = note: expected one of: ! ( - FALSE FLOAT FUNCTION IDENT IF INT STRING TRUE [ {\n" +
+		PROMPT
+	assert.Equal(t, expect, out.String())
+}
+
+func TestIsComplete(t *testing.T) {
+	table := []struct {
+		input  string
+		expect bool
+	}{
+		{"1 + 2", true},
+		{"let x = 5;", true},
+		{"fn(x) {", false},
+		{"f(1,", false},
+		{"let x =", false},
+		{"1 +", false},
+		{"if (x) { 1 } else", false},
+		{"}", true},
+		{"let = 1", true},
+		{`"open`, true},
+		{"1 /* still", false},
+		{"1 // done", true},
+		{"x + break = 1", true},
+	}
+
+	for _, data := range table {
+		assert.Equal(t, data.expect, isComplete(data.input), data.input)
+	}
+}
+
+func TestCommands(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "lib.mk")
+	require.NoError(t, os.WriteFile(file, []byte("let double = fn(x) { x * 2 };\nlet ten = 10;"), 0o644))
+
+	input := `:tokens let x
+:ast -a
+let b = "b"
+:load ` + file + `
+double(ten)
+:env
+:reset
+:env
+ten
+:foo
+:quit
+1 + 1
+`
+
+	var out bytes.Buffer
+	Start(strings.NewReader(input), &out)
+
+	expect := PROMPT + "1:1\tLET\t\"let\"\n" +
+		"1:5\tIDENT\t\"x\"\n" +
+		PROMPT + "ExpressionStatement 1:1-1:3 token=\"-\"\n" +
+		"  Expression: PrefixExpression 1:1-1:3 token=\"-\"\n" +
+		"    Right: Identifier 1:2-1:3 token=\"a\" Value=\"a\"\n" +
+		PROMPT +
+		PROMPT +
+		PROMPT + "20\n" +
+		PROMPT + "b = b\n" +
+		"double = fn(x) {(x * 2)}\n" +
+		"ten = 10\n" +
+		PROMPT +
+		PROMPT +
+		PROMPT + "ERROR: identifier not found: ten\n" +
+		PROMPT + "unknown command :foo, type :help for a list\n" +
+		PROMPT
+	assert.Equal(t, expect, out.String())
+}
+
+func TestComplete(t *testing.T) {
+	s := newSession(&bytes.Buffer{})
+	s.eval("let value = 1; let variable = 2; let foo = 3;", replFilename)
+
+	table := []struct {
+		line        string
+		pos         int
+		head        string
+		completions []string
+		tail        string
+	}{
+		{"va", 2, "", []string{"value", "variable"}, ""},
+		{"1 + f", 5, "1 + ", []string{"false", "fn", "foo", "for"}, ""},
+		{"r(x)", 1, "", []string{"return"}, "(x)"},
+		{":lo", 3, ":", []string{"load"}, ""},
+		{":ast le", 7, ":ast ", []string{"let"}, ""},
+		{"zzz", 3, "", []string{}, ""},
+	}
+
+	for _, data := range table {
+		head, completions, tail := s.complete(data.line, data.pos)
+		assert.Equal(t, data.head, head, data.line)
+		assert.Equal(t, data.completions, completions, data.line)
+		assert.Equal(t, data.tail, tail, data.line)
+	}
+}
+
+type interruptReader struct {
+	lines []string
+}
+
+func (r *interruptReader) ReadLine(prompt string) (string, error) {
+	if len(r.lines) == 0 {
+		return "", io.EOF
+	}
+	line := r.lines[0]
+	r.lines = r.lines[1:]
+	if line == "^C" {
+		return "", errInterrupted
+	}
+	return line, nil
+}
+
+func TestInterrupt(t *testing.T) {
+	var out bytes.Buffer
+	reader := &interruptReader{lines: []string{"fn(x) {", "^C", "1 + 2"}}
+	run(reader, newSession(&out))
+
+	assert.Equal(t, "3\n", out.String())
+}
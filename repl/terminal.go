@@ -0,0 +1,113 @@
+package repl
+
+import (
+	"compiler/token"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/peterh/liner"
+)
+
+// interactive session on the terminal with line editing, history kept
+// in historyPath across sessions, and tab completion
+func StartTerminal(historyPath string) {
+	line := liner.NewLiner()
+	defer line.Close()
+
+	line.SetCtrlCAborts(true)
+	loadHistory(line, historyPath)
+	defer saveHistory(line, historyPath)
+
+	s := newSession(os.Stdout)
+	line.SetWordCompleter(s.complete)
+
+	run(&terminalReader{line: line}, s)
+}
+
+type terminalReader struct {
+	line *liner.State
+}
+
+func (r *terminalReader) ReadLine(prompt string) (string, error) {
+	input, err := r.line.Prompt(prompt)
+	if err == liner.ErrPromptAborted {
+		return "", errInterrupted
+	}
+	if err != nil {
+		return "", err
+	}
+	if strings.TrimSpace(input) != "" {
+		r.line.AppendHistory(input)
+	}
+	return input, nil
+}
+
+func loadHistory(line *liner.State, path string) {
+	if path == "" {
+		return
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		// no history yet
+		return
+	}
+	defer f.Close()
+	line.ReadHistory(f)
+}
+
+func saveHistory(line *liner.State, path string) {
+	if path == "" {
+		return
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "can not save history: %v\n", err)
+		return
+	}
+	defer f.Close()
+	line.WriteHistory(f)
+}
+
+// complete the word before pos with keywords and bound names,
+// or with command names at the start of a meta command
+func (s *session) complete(line string, pos int) (string, []string, string) {
+	runes := []rune(line)
+	if pos > len(runes) {
+		pos = len(runes)
+	}
+	start := pos
+	for start > 0 && isWordRune(runes[start-1]) {
+		start--
+	}
+	head, word, tail := string(runes[:start]), string(runes[start:pos]), string(runes[pos:])
+
+	candidates := []string{}
+	if strings.TrimSpace(head) == commandPrefix {
+		for name := range commands {
+			candidates = append(candidates, name)
+		}
+	} else if !isCommand(head) || strings.Contains(head, " ") {
+		candidates = append(candidates, token.Keywords()...)
+		candidates = append(candidates, s.env.Names()...)
+	}
+
+	completions := []string{}
+	seen := map[string]bool{}
+	for _, candidate := range candidates {
+		if strings.HasPrefix(candidate, word) && !seen[candidate] {
+			seen[candidate] = true
+			completions = append(completions, candidate)
+		}
+	}
+	sort.Strings(completions)
+	return head, completions, tail
+}
+
+func isWordRune(r rune) bool {
+	return r >= 'a' && r <= 'z' ||
+		r >= 'A' && r <= 'Z' ||
+		r >= '0' && r <= '9' ||
+		r == '_'
+}
@@ -1,42 +1,120 @@
 package token
 
+import (
+	"fmt"
+	"sort"
+)
+
 type TokenType string
 
 type Token struct {
 	Type    TokenType
 	Literal string
+	Pos     Position // first character of the token
+	End     Position // one past the last character of the token
+
+	// only filled in when the lexer keeps trivia
+	Leading  []Trivia
+	Trailing []Trivia
+}
+
+type TriviaKind string
+
+const (
+	TRIVIA_WHITESPACE TriviaKind = "WHITESPACE"
+	TRIVIA_NEWLINE    TriviaKind = "NEWLINE"
+	TRIVIA_COMMENT    TriviaKind = "COMMENT"
+)
+
+// source around a token that does not change its meaning
+type Trivia struct {
+	Kind TriviaKind
+	Text string
+	Pos  Position
+}
+
+// the token as written, with its leading and trailing trivia
+func (t Token) FullText() string {
+	s := ""
+	for _, trivia := range t.Leading {
+		s += trivia.Text
+	}
+	s += t.Literal
+	for _, trivia := range t.Trailing {
+		s += trivia.Text
+	}
+	return s
+}
+
+// location in source, Line and Column start at 1, Offset is in bytes
+type Position struct {
+	Filename string
+	Offset   int
+	Line     int
+	Column   int
+}
+
+func (p Position) IsValid() bool {
+	return p.Line > 0
+}
+
+// file:line:column, or line:column if there is no file name
+func (p Position) String() string {
+	if !p.IsValid() {
+		if p.Filename != "" {
+			return p.Filename
+		}
+		return "-"
+	}
+	s := fmt.Sprintf("%d:%d", p.Line, p.Column)
+	if p.Filename != "" {
+		s = p.Filename + ":" + s
+	}
+	return s
 }
 
 const (
 	ILLEGAL TokenType = "ILLEGAL"
 	EOF     TokenType = "EOF"
 
+	COMMENT TokenType = "COMMENT"
+
 	IDENT  TokenType = "IDENT"
 	INT    TokenType = "INT"
+	FLOAT  TokenType = "FLOAT"
 	STRING TokenType = "STRING"
 
 	// Operators
-	ASSIGN     TokenType = "="
-	EQ         TokenType = "=="
-	NE         TokenType = "!="
-	PLUS       TokenType = "+"
-	PLUSPLUS   TokenType = "++"
-	MINUS      TokenType = "-"
-	MINUSMINUS TokenType = "--"
-	MULTI      TokenType = "*"
-	DIVIDE     TokenType = "/"
-	BANG       TokenType = "!"
-	WHAT       TokenType = "?"
-	LT         TokenType = "<"
-	LE         TokenType = "<="
-	GT         TokenType = ">"
-	GE         TokenType = ">="
+	ASSIGN        TokenType = "="
+	PLUS_ASSIGN   TokenType = "+="
+	MINUS_ASSIGN  TokenType = "-="
+	MULTI_ASSIGN  TokenType = "*="
+	DIVIDE_ASSIGN TokenType = "/="
+	EQ            TokenType = "=="
+	NE            TokenType = "!="
+	PLUS          TokenType = "+"
+	PLUSPLUS      TokenType = "++"
+	MINUS         TokenType = "-"
+	MINUSMINUS    TokenType = "--"
+	MULTI         TokenType = "*"
+	DIVIDE        TokenType = "/"
+	BANG          TokenType = "!"
+	WHAT          TokenType = "?"
+	LT            TokenType = "<"
+	LE            TokenType = "<="
+	GT            TokenType = ">"
+	GE            TokenType = ">="
+	AND           TokenType = "&&"
+	OR            TokenType = "||"
 
 	LPAREN    TokenType = "("
 	RPAREN    TokenType = ")"
 	LBRACE    TokenType = "{"
 	RBRACE    TokenType = "}"
+	LBRACKET  TokenType = "["
+	RBRACKET  TokenType = "]"
 	COMMA     TokenType = ","
+	COLON     TokenType = ":"
 	SEMICOLON TokenType = ";"
 
 	// Keywords
@@ -47,16 +125,26 @@ const (
 	IF       TokenType = "IF"
 	ELSE     TokenType = "ELSE"
 	RETURN   TokenType = "RETURN"
+	WHILE    TokenType = "WHILE"
+	FOR      TokenType = "FOR"
+	IN       TokenType = "IN"
+	BREAK    TokenType = "BREAK"
+	CONTINUE TokenType = "CONTINUE"
 )
 
 var keywords = map[string]TokenType{
-	"fn":     FUNCTION,
-	"let":    LET,
-	"true":   TRUE,
-	"false":  FALSE,
-	"if":     IF,
-	"else":   ELSE,
-	"return": RETURN,
+	"fn":       FUNCTION,
+	"let":      LET,
+	"true":     TRUE,
+	"false":    FALSE,
+	"if":       IF,
+	"else":     ELSE,
+	"return":   RETURN,
+	"while":    WHILE,
+	"for":      FOR,
+	"in":       IN,
+	"break":    BREAK,
+	"continue": CONTINUE,
 }
 
 func LoopUpKeywords(key string) TokenType {
@@ -65,3 +153,13 @@ func LoopUpKeywords(key string) TokenType {
 	}
 	return IDENT
 }
+
+// all keywords, sorted
+func Keywords() []string {
+	words := []string{}
+	for word := range keywords {
+		words = append(words, word)
+	}
+	sort.Strings(words)
+	return words
+}